@@ -96,9 +96,10 @@ assert_status "200" "$valid_status" "valid token request should succeed"
 log "Test: rate limiting for token path returns 429"
 # Build a token with small rate limit for this test.
 "${DC[@]}" exec -T postgres psql -U html2pdf -d html2pdf -c \
-  "INSERT INTO tokens(token, rate_limit, scope, comment)
-   VALUES ('int-low-limit-token', 1, '{\"api\": true}'::jsonb, 'integration low limit')
-   ON CONFLICT (token) DO UPDATE SET rate_limit = EXCLUDED.rate_limit, scope = EXCLUDED.scope;" >/dev/null
+  "INSERT INTO tokens(token_hash, token_prefix, rate_limit, scope, comment)
+   VALUES (encode(sha256(convert_to('int-low-limit-token', 'UTF8')), 'hex'), left('int-low-limit-token', 8),
+           1, '{\"api\": true}'::jsonb, 'integration low limit')
+   ON CONFLICT (token_hash) DO UPDATE SET rate_limit = EXCLUDED.rate_limit, scope = EXCLUDED.scope;" >/dev/null
 
 sleep 65
 
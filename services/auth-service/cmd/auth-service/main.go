@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"auth-service/internal/config"
 	"auth-service/internal/http/server"
 	"auth-service/internal/infra/logging"
 	"auth-service/internal/infra/postgres"
+	"auth-service/internal/infra/tracing"
 	"auth-service/internal/tokens"
 )
 
@@ -30,21 +32,31 @@ func main() {
 	)
 	logging.SetLogLevel(cfg.Logger.Level)
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logging.Error("Tracing setup failed; spans will not be exported", "error", err)
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+
 	// Token cache + repository
 	cache := tokens.NewCache()
 	db := postgres.NewDB()
 	repo := postgres.NewTokenRepository(db, cfg.PostgresDSN)
 
+	reloader := tokens.NewReloader(repo, cache, cfg.TokenReloadInterval)
+
 	// Initial token load (may fail if DB not ready yet).
-	if err := tokens.NewReloader(repo, cache, cfg.TokenReloadInterval).LoadOnce(context.Background()); err != nil {
+	if err := reloader.LoadOnce(context.Background()); err != nil {
 		logging.Error("Initial token load failed", "error", err)
 	} else {
 		logging.Info("Token store ready")
 	}
 
-	// Periodic reload.
-	reloader := tokens.NewReloader(repo, cache, cfg.TokenReloadInterval)
+	// Periodic reload, plus incremental updates as tokens change.
 	reloader.Start(context.Background())
+	if cfg.TokenListen {
+		reloader.Listen(context.Background(), postgres.NewTokenListener(cfg.PostgresDSN))
+	}
 
 	store := server.NewRateLimitStore(cfg)
 
@@ -52,8 +64,13 @@ func main() {
 		Config:     cfg,
 		TokenCache: cache,
 		Store:      store,
+		TokenAdmin: repo,
+		Reloader:   reloader,
 	}); err != nil {
 		logging.Error("Server stopped with error", "error", err)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = shutdownTracing(ctx)
+		cancel()
 		os.Exit(1)
 	}
 }
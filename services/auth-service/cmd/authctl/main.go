@@ -0,0 +1,420 @@
+// Command authctl manages auth-service tokens and the token schema directly in Postgres, using
+// the auth-service configuration.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"auth-service/deploy/postgres/migrations"
+	"auth-service/internal/config"
+	"auth-service/internal/infra/postgres"
+	"auth-service/internal/tokens"
+)
+
+const usage = `Usage: authctl [-config path] [-dsn dsn] [-json] <command> [flags]
+
+Token commands (<id> is the token digest or a unique leading part of at least 8 characters):
+  list                                          list tokens
+  show <id>                                     show a token
+  create [-rate-limit n] [-scopes a,b] [-comment s] [-not-before t] [-expires-at t]
+                                                create a token and print it (shown only once)
+  set <id> [-rate-limit n] [-scopes a,b] [-comment s] [-not-before t] [-expires-at t]
+                                                change a token; an empty time clears it
+  rotate <id> [-grace d]                        issue a new token, revoke the old one after d
+  revoke <id> [-at t]                           revoke a token now or at t
+
+Schema commands:
+  migrate [-dir path]                           apply pending migrations (embedded unless -dir is set)
+  verify                                        check the schema
+
+Times are RFC 3339 (2026-01-02T15:04:05Z), durations Go durations (90s, 24h).
+`
+
+// tokenStore is the part of postgres.TokenRepository authctl uses.
+type tokenStore interface {
+	ListTokens(ctx context.Context) ([]tokens.Record, error)
+	ResolveToken(ctx context.Context, id string) (tokens.Record, error)
+	CreateToken(ctx context.Context, rec tokens.Record) (tokens.Record, error)
+	UpdateToken(ctx context.Context, rec tokens.Record) error
+	RevokeToken(ctx context.Context, id string, at time.Time) error
+	RotateToken(ctx context.Context, id string, next tokens.Record, revokeAt time.Time) (tokens.Record, error)
+}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "authctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("authctl", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), usage) }
+	configPath := flags.String("config", "", "config file (default $CONFIG_PATH or config/auth-service.yaml)")
+	dsn := flags.String("dsn", "", "Postgres DSN (default postgres_dsn from the config)")
+	asJSON := flags.Bool("json", false, "print JSON instead of tables")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+	cmd, cmdArgs := flags.Arg(0), flags.Args()[1:]
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *dsn != "" {
+		cfg.PostgresDSN = *dsn
+	}
+	db := postgres.NewDB()
+
+	switch cmd {
+	case "migrate":
+		return migrate(ctx, db, cfg.PostgresDSN, cmdArgs, out)
+	case "verify":
+		conn, err := db.Get(cfg.PostgresDSN)
+		if err != nil {
+			return err
+		}
+		if err := postgres.VerifySchema(conn); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "schema ok")
+		return nil
+	}
+	c := &cli{repo: postgres.NewTokenRepository(db, cfg.PostgresDSN), out: out, json: *asJSON, now: time.Now}
+	return c.run(ctx, cmd, cmdArgs)
+}
+
+// loadConfig loads the auth-service config like the service does, returning its panics as errors.
+func loadConfig(path string) (cfg config.Config, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	if path != "" {
+		return config.LoadFrom(path), nil
+	}
+	return config.Load(), nil
+}
+
+func migrate(ctx context.Context, db *postgres.DB, dsn string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := flags.String("dir", "", "directory with *.sql migrations")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	conn, err := db.Get(dsn)
+	if err != nil {
+		return err
+	}
+	var files fs.FS = migrations.FS
+	if *dir != "" {
+		files = os.DirFS(*dir)
+	}
+	applied, err := postgres.Migrate(ctx, conn, files)
+	for _, name := range applied {
+		fmt.Fprintln(out, "applied", name)
+	}
+	if err != nil {
+		return err
+	}
+	if err := postgres.VerifySchema(conn); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "schema ok")
+	return nil
+}
+
+type cli struct {
+	repo tokenStore
+	out  io.Writer
+	json bool
+	now  func() time.Time
+}
+
+func (c *cli) run(ctx context.Context, cmd string, args []string) error {
+	switch cmd {
+	case "list":
+		if _, err := parseArgs(flag.NewFlagSet(cmd, flag.ContinueOnError), args, false); err != nil {
+			return err
+		}
+		recs, err := c.repo.ListTokens(ctx)
+		if err != nil {
+			return err
+		}
+		return c.printList(recs)
+	case "show":
+		id, err := parseArgs(flag.NewFlagSet(cmd, flag.ContinueOnError), args, true)
+		if err != nil {
+			return err
+		}
+		rec, err := c.repo.ResolveToken(ctx, id)
+		if err != nil {
+			return err
+		}
+		return c.print(rec, "")
+	case "create":
+		return c.create(ctx, args)
+	case "set":
+		return c.set(ctx, args)
+	case "rotate":
+		return c.rotate(ctx, args)
+	case "revoke":
+		return c.revoke(ctx, args)
+	}
+	return fmt.Errorf("unknown command %q (see authctl -h)", cmd)
+}
+
+// settings are the flags shared by create and set.
+type settings struct {
+	rateLimit int
+	scopes    string
+	comment   string
+	notBefore string
+	expiresAt string
+}
+
+func (s *settings) register(flags *flag.FlagSet) {
+	flags.IntVar(&s.rateLimit, "rate-limit", tokens.DefaultRateLimit, "requests per rate_interval (0 = unlimited)")
+	flags.StringVar(&s.scopes, "scopes", "api", "comma-separated scopes")
+	flags.StringVar(&s.comment, "comment", "", "free-form comment")
+	flags.StringVar(&s.notBefore, "not-before", "", "RFC 3339 time the token becomes valid")
+	flags.StringVar(&s.expiresAt, "expires-at", "", "RFC 3339 time the token expires")
+}
+
+// apply copies the flags set on the command line (all of them with all=true) to rec.
+func (s *settings) apply(flags *flag.FlagSet, rec *tokens.Record, all bool) error {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var err error
+	if all || set["rate-limit"] {
+		rec.RateLimit = s.rateLimit
+	}
+	if all || set["scopes"] {
+		rec.Scope = tokens.NewScope(splitList(s.scopes))
+	}
+	if all || set["comment"] {
+		rec.Comment = s.comment
+	}
+	if all || set["not-before"] {
+		if rec.NotBefore, err = parseTime(s.notBefore); err != nil {
+			return fmt.Errorf("-not-before: %w", err)
+		}
+	}
+	if all || set["expires-at"] {
+		if rec.ExpiresAt, err = parseTime(s.expiresAt); err != nil {
+			return fmt.Errorf("-expires-at: %w", err)
+		}
+	}
+	return rec.Validate()
+}
+
+func (c *cli) create(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	var s settings
+	s.register(flags)
+	if _, err := parseArgs(flags, args, false); err != nil {
+		return err
+	}
+	var rec tokens.Record
+	if err := s.apply(flags, &rec, true); err != nil {
+		return err
+	}
+	rec, secret, err := tokens.Issue(rec)
+	if err != nil {
+		return err
+	}
+	if rec, err = c.repo.CreateToken(ctx, rec); err != nil {
+		return err
+	}
+	return c.print(rec, secret)
+}
+
+func (c *cli) set(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("set", flag.ContinueOnError)
+	var s settings
+	s.register(flags)
+	id, err := parseArgs(flags, args, true)
+	if err != nil {
+		return err
+	}
+	rec, err := c.repo.ResolveToken(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.apply(flags, &rec, false); err != nil {
+		return err
+	}
+	if err := c.repo.UpdateToken(ctx, rec); err != nil {
+		return err
+	}
+	return c.print(rec, "")
+}
+
+func (c *cli) rotate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("rotate", flag.ContinueOnError)
+	grace := flags.Duration("grace", 0, "keep the old token valid for this long")
+	id, err := parseArgs(flags, args, true)
+	if err != nil {
+		return err
+	}
+	if *grace < 0 {
+		return errors.New("-grace must be >= 0")
+	}
+	old, err := c.repo.ResolveToken(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := c.now()
+	if !old.RevokedAt.IsZero() && !now.Before(old.RevokedAt) {
+		return fmt.Errorf("token %s is revoked", old.ID[:12])
+	}
+	next := old
+	next.CreatedAt, next.RevokedAt = time.Time{}, time.Time{}
+	next, secret, err := tokens.Issue(next)
+	if err != nil {
+		return err
+	}
+	if next, err = c.repo.RotateToken(ctx, old.ID, next, now.Add(*grace)); err != nil {
+		return err
+	}
+	return c.print(next, secret)
+}
+
+func (c *cli) revoke(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	atFlag := flags.String("at", "", "RFC 3339 revocation time (default now)")
+	id, err := parseArgs(flags, args, true)
+	if err != nil {
+		return err
+	}
+	at := c.now()
+	if *atFlag != "" {
+		if at, err = parseTime(*atFlag); err != nil {
+			return fmt.Errorf("-at: %w", err)
+		}
+	}
+	rec, err := c.repo.ResolveToken(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := c.repo.RevokeToken(ctx, rec.ID, at); err != nil {
+		return err
+	}
+	if rec.RevokedAt.IsZero() || at.Before(rec.RevokedAt) {
+		rec.RevokedAt = at
+	}
+	return c.print(rec, "")
+}
+
+func (c *cli) print(rec tokens.Record, secret string) error {
+	v := rec.View(c.now(), secret)
+	if c.json {
+		return c.writeJSON(v)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	if v.Token != "" {
+		fmt.Fprintf(w, "token:\t%s\t(store it now, it cannot be shown again)\n", v.Token)
+	}
+	fmt.Fprintf(w, "id:\t%s\n", v.ID)
+	fmt.Fprintf(w, "prefix:\t%s\n", v.Prefix)
+	fmt.Fprintf(w, "status:\t%s\n", v.Status)
+	fmt.Fprintf(w, "rate_limit:\t%d\n", v.RateLimit)
+	fmt.Fprintf(w, "scopes:\t%s\n", strings.Join(v.Scopes, ","))
+	fmt.Fprintf(w, "comment:\t%s\n", v.Comment)
+	fmt.Fprintf(w, "created_at:\t%s\n", formatTime(&v.CreatedAt))
+	fmt.Fprintf(w, "not_before:\t%s\n", formatTime(v.NotBefore))
+	fmt.Fprintf(w, "expires_at:\t%s\n", formatTime(v.ExpiresAt))
+	fmt.Fprintf(w, "revoked_at:\t%s\n", formatTime(v.RevokedAt))
+	return w.Flush()
+}
+
+func (c *cli) printList(recs []tokens.Record) error {
+	now := c.now()
+	if c.json {
+		views := make([]tokens.View, 0, len(recs))
+		for _, rec := range recs {
+			views = append(views, rec.View(now, ""))
+		}
+		return c.writeJSON(views)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPREFIX\tSTATUS\tRATE_LIMIT\tSCOPES\tEXPIRES_AT\tCOMMENT")
+	for _, rec := range recs {
+		v := rec.View(now, "")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			v.ID[:12], v.Prefix, v.Status, v.RateLimit, strings.Join(v.Scopes, ","), formatTime(v.ExpiresAt), v.Comment)
+	}
+	return w.Flush()
+}
+
+func (c *cli) writeJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// parseArgs parses a command's flags, which may come before or after the token ID. With wantID
+// exactly one positional argument is required, otherwise none.
+func parseArgs(flags *flag.FlagSet, args []string, wantID bool) (string, error) {
+	var id string
+	if wantID && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	rest := flags.Args()
+	if wantID && id == "" && len(rest) > 0 {
+		id, rest = rest[0], rest[1:]
+	}
+	switch {
+	case len(rest) > 0:
+		return "", fmt.Errorf("%s: unexpected arguments %v", flags.Name(), rest)
+	case wantID && id == "":
+		return "", fmt.Errorf("%s: missing token id", flags.Name())
+	}
+	return id, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/tokens"
+)
+
+type fakeStore struct {
+	recs map[string]tokens.Record
+}
+
+func (f *fakeStore) ListTokens(ctx context.Context) ([]tokens.Record, error) {
+	var out []tokens.Record
+	for _, rec := range f.recs {
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+func (f *fakeStore) ResolveToken(ctx context.Context, id string) (tokens.Record, error) {
+	var found []tokens.Record
+	for full, rec := range f.recs {
+		if strings.HasPrefix(full, id) {
+			found = append(found, rec)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return tokens.Record{}, domain.ErrTokenNotFound
+	case 1:
+		return found[0], nil
+	}
+	return tokens.Record{}, domain.ErrAmbiguousTokenID
+}
+
+func (f *fakeStore) CreateToken(ctx context.Context, rec tokens.Record) (tokens.Record, error) {
+	f.recs[rec.ID] = rec
+	return rec, nil
+}
+
+func (f *fakeStore) UpdateToken(ctx context.Context, rec tokens.Record) error {
+	f.recs[rec.ID] = rec
+	return nil
+}
+
+func (f *fakeStore) RevokeToken(ctx context.Context, id string, at time.Time) error {
+	rec := f.recs[id]
+	rec.RevokedAt = at
+	f.recs[id] = rec
+	return nil
+}
+
+func (f *fakeStore) RotateToken(ctx context.Context, id string, next tokens.Record, revokeAt time.Time) (tokens.Record, error) {
+	f.recs[next.ID] = next
+	return next, f.RevokeToken(ctx, id, revokeAt)
+}
+
+func newTestCLI() (*cli, *fakeStore, *bytes.Buffer) {
+	store := &fakeStore{recs: map[string]tokens.Record{}}
+	out := &bytes.Buffer{}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &cli{repo: store, out: out, json: true, now: func() time.Time { return now }}, store, out
+}
+
+func TestCLI_CreateSetRotateRevoke(t *testing.T) {
+	c, store, out := newTestCLI()
+	ctx := context.Background()
+
+	if err := c.run(ctx, "create", []string{"-rate-limit", "5", "-scopes", "api, inject", "-expires-at", "2026-06-01T00:00:00Z"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var created tokens.View
+	if err := json.Unmarshal(out.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.Token == "" || created.ID != tokens.Digest(created.Token) || created.RateLimit != 5 ||
+		strings.Join(created.Scopes, ",") != "api,inject" || created.ExpiresAt == nil {
+		t.Fatalf("unexpected created token: %+v", created)
+	}
+
+	// Flags may follow the ID; only the flags given change.
+	out.Reset()
+	if err := c.run(ctx, "set", []string{created.ID[:8], "-expires-at", "", "-comment", "customer x"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	rec := store.recs[created.ID]
+	if !rec.ExpiresAt.IsZero() || rec.Comment != "customer x" || rec.RateLimit != 5 || !rec.Scope["inject"] {
+		t.Fatalf("unexpected record after set: %+v", rec)
+	}
+
+	out.Reset()
+	if err := c.run(ctx, "rotate", []string{"-grace", "1h", created.ID[:8]}); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	var rotated tokens.View
+	_ = json.Unmarshal(out.Bytes(), &rotated)
+	if rotated.Token == "" || rotated.Token == created.Token || rotated.Comment != "customer x" {
+		t.Fatalf("unexpected rotated token: %+v", rotated)
+	}
+	if got := store.recs[created.ID].RevokedAt; !got.Equal(c.now().Add(time.Hour)) {
+		t.Fatalf("expected the old token to be revoked after the grace period, got %v", got)
+	}
+
+	out.Reset()
+	if err := c.run(ctx, "revoke", []string{rotated.ID}); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if !strings.Contains(out.String(), `"status": "revoked"`) {
+		t.Fatalf("expected revoked status, got %s", out.String())
+	}
+	if err := c.run(ctx, "rotate", []string{rotated.ID}); err == nil {
+		t.Fatalf("expected rotating a revoked token to fail")
+	}
+}
+
+func TestCLI_ListTable(t *testing.T) {
+	c, store, out := newTestCLI()
+	c.json = false
+	id := tokens.Digest("first-token-0001")
+	store.recs[id] = tokens.Record{ID: id, Prefix: "first-to", RateLimit: 60, Scope: tokens.Scope{"api": true}, Comment: "bootstrap"}
+
+	if err := c.run(context.Background(), "list", nil); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") {
+		t.Fatalf("unexpected table:\n%s", out.String())
+	}
+	for _, want := range []string{id[:12], "first-to", "active", "60", "api", "bootstrap"} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("expected %q in %q", want, lines[1])
+		}
+	}
+}
+
+func TestCLI_Errors(t *testing.T) {
+	c, _, _ := newTestCLI()
+	ctx := context.Background()
+	tests := [][]string{
+		{"show"},
+		{"show", "aaaaaaaa", "extra"},
+		{"list", "extra"},
+		{"create", "-rate-limit", "-1"},
+		{"create", "-scopes", "api ops"},
+		{"create", "-expires-at", "tomorrow"},
+		{"revoke", "-at", "now", "aaaaaaaa"},
+		{"unknown"},
+	}
+	for _, args := range tests {
+		if err := c.run(ctx, args[0], args[1:]); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+-- Store API tokens as SHA-256 digests (hex) plus a short public prefix instead of in plaintext,
+-- converting existing rows in place. The prefix is left(token, least(8, length(token) / 2)) and
+-- must match tokens.Prefix in the auth-service.
+
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS token_hash TEXT;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS token_prefix TEXT;
+
+-- The result columns change, which CREATE OR REPLACE cannot do.
+DROP FUNCTION IF EXISTS fn_fetch_auth_tokens();
+
+DO $migration$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token'
+    ) THEN
+        UPDATE tokens
+        SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex'),
+            token_prefix = left(token, least(8, length(token) / 2));
+
+        ALTER TABLE tokens DROP CONSTRAINT IF EXISTS tokens_pkey;
+        ALTER TABLE tokens DROP COLUMN token;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conrelid = 'tokens'::regclass
+          AND conname = 'tokens_pkey'
+    ) THEN
+        ALTER TABLE tokens ADD CONSTRAINT tokens_pkey PRIMARY KEY (token_hash);
+    END IF;
+
+    -- Rejects plaintext tokens inserted by mistake.
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conrelid = 'tokens'::regclass
+          AND conname = 'tokens_token_hash_check'
+    ) THEN
+        ALTER TABLE tokens ADD CONSTRAINT tokens_token_hash_check CHECK (token_hash ~ '^[0-9a-f]{64}$');
+    END IF;
+END;
+$migration$;
+
+ALTER TABLE tokens ALTER COLUMN token_prefix SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tokens_token_prefix ON tokens (token_prefix);
+
+CREATE FUNCTION fn_fetch_auth_tokens()
+RETURNS TABLE (token_hash TEXT, token_prefix TEXT, rate_limit INTEGER, scope JSONB)
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT t.token_hash, t.token_prefix, t.rate_limit, t.scope
+    FROM tokens t;
+$$;
+
+CREATE OR REPLACE FUNCTION fn_verify_tokens_schema() RETURNS void
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.tables
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens table';
+    END IF;
+
+    IF EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: plaintext tokens.token column present (apply 002_hash_tokens.sql)';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token_hash'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.token_hash column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token_prefix'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.token_prefix column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'rate_limit'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.rate_limit column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'created_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.created_at column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'scope'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.scope column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'comment'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.comment column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_indexes
+        WHERE schemaname = 'public'
+          AND tablename = 'tokens'
+          AND indexname = 'idx_tokens_created_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing idx_tokens_created_at index';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_indexes
+        WHERE schemaname = 'public'
+          AND tablename = 'tokens'
+          AND indexname = 'idx_tokens_token_prefix'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing idx_tokens_token_prefix index';
+    END IF;
+END;
+$$;
@@ -0,0 +1,162 @@
+-- Validity window and revocation for API tokens. All three are optional; the auth-service compares
+-- them with its clock on every check, so a future expires_at or revoked_at takes effect on time
+-- without waiting for a reload.
+
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS not_before TIMESTAMPTZ;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
+
+-- The result columns change, which CREATE OR REPLACE cannot do.
+DROP FUNCTION IF EXISTS fn_fetch_auth_tokens();
+
+CREATE FUNCTION fn_fetch_auth_tokens()
+RETURNS TABLE (
+    token_hash TEXT,
+    token_prefix TEXT,
+    rate_limit INTEGER,
+    scope JSONB,
+    not_before TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+)
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT t.token_hash, t.token_prefix, t.rate_limit, t.scope, t.not_before, t.expires_at, t.revoked_at
+    FROM tokens t;
+$$;
+
+CREATE OR REPLACE FUNCTION fn_verify_tokens_schema() RETURNS void
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.tables
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens table';
+    END IF;
+
+    IF EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: plaintext tokens.token column present (apply 002_hash_tokens.sql)';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token_hash'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.token_hash column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token_prefix'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.token_prefix column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'rate_limit'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.rate_limit column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'created_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.created_at column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'scope'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.scope column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'comment'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.comment column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'not_before'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.not_before column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'expires_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.expires_at column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'revoked_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.revoked_at column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_indexes
+        WHERE schemaname = 'public'
+          AND tablename = 'tokens'
+          AND indexname = 'idx_tokens_created_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing idx_tokens_created_at index';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_indexes
+        WHERE schemaname = 'public'
+          AND tablename = 'tokens'
+          AND indexname = 'idx_tokens_token_prefix'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing idx_tokens_token_prefix index';
+    END IF;
+END;
+$$;
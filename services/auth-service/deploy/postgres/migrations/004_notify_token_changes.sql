@@ -0,0 +1,177 @@
+-- Notify listeners of token changes so auth-service instances update their cache right away instead
+-- of waiting for the next full reload. The payload on channel auth_tokens_changed is the token_hash of
+-- the changed row, or '*' after a TRUNCATE; listeners fetch the row's current state themselves, so the
+-- payload never carries more than the digest.
+
+CREATE OR REPLACE FUNCTION fn_notify_token_change() RETURNS trigger
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF TG_OP = 'TRUNCATE' THEN
+        PERFORM pg_notify('auth_tokens_changed', '*');
+        RETURN NULL;
+    END IF;
+    -- An UPDATE that changes the digest removes the old key and adds the new one; identical
+    -- notifications within a transaction are delivered once.
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM pg_notify('auth_tokens_changed', OLD.token_hash);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM pg_notify('auth_tokens_changed', NEW.token_hash);
+    END IF;
+    RETURN NULL;
+END;
+$$;
+
+CREATE OR REPLACE TRIGGER trg_tokens_notify
+AFTER INSERT OR UPDATE OR DELETE ON tokens
+FOR EACH ROW EXECUTE FUNCTION fn_notify_token_change();
+
+CREATE OR REPLACE TRIGGER trg_tokens_notify_truncate
+AFTER TRUNCATE ON tokens
+FOR EACH STATEMENT EXECUTE FUNCTION fn_notify_token_change();
+
+CREATE OR REPLACE FUNCTION fn_verify_tokens_schema() RETURNS void
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.tables
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens table';
+    END IF;
+
+    IF EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: plaintext tokens.token column present (apply 002_hash_tokens.sql)';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token_hash'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.token_hash column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'token_prefix'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.token_prefix column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'rate_limit'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.rate_limit column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'created_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.created_at column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'scope'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.scope column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'comment'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.comment column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'not_before'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.not_before column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'expires_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.expires_at column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = 'public'
+          AND table_name = 'tokens'
+          AND column_name = 'revoked_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing tokens.revoked_at column';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_indexes
+        WHERE schemaname = 'public'
+          AND tablename = 'tokens'
+          AND indexname = 'idx_tokens_created_at'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing idx_tokens_created_at index';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_indexes
+        WHERE schemaname = 'public'
+          AND tablename = 'tokens'
+          AND indexname = 'idx_tokens_token_prefix'
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing idx_tokens_token_prefix index';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_trigger
+        WHERE tgrelid = 'public.tokens'::regclass
+          AND tgname = 'trg_tokens_notify'
+          AND NOT tgisinternal
+    ) THEN
+        RAISE EXCEPTION 'auth-service schema check failed: missing trg_tokens_notify trigger';
+    END IF;
+END;
+$$;
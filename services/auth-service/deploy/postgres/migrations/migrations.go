@@ -0,0 +1,9 @@
+// Package migrations embeds the schema migrations so authctl can apply them without a checkout.
+package migrations
+
+import "embed"
+
+// FS holds the *.sql migrations, applied in name order.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -10,16 +10,30 @@ import (
 type Config struct {
 	ListenAddr string `yaml:"listen_addr"`
 
+	// MetricsListenAddr serves Prometheus metrics on a separate listener that is not
+	// routed through Envoy. Empty disables metrics.
+	MetricsListenAddr string `yaml:"metrics_listen_addr"`
+
+	// AdminListenAddr serves the token admin API on a separate listener that is not routed
+	// through Envoy. Requests need an API key with the admin scope. Empty disables it.
+	AdminListenAddr string `yaml:"admin_listen_addr"`
+
 	PostgresDSN string `yaml:"postgres_dsn"`
 
 	Logger LoggerConfig `yaml:"logger"`
 
+	Tracing TracingConfig `yaml:"tracing"`
+
 	RedisAddr     string `yaml:"redis_addr"`
 	RedisPassword string `yaml:"redis_password"`
 	RedisRateDB   int    `yaml:"redis_rate_db"`
 
 	TokenReloadInterval time.Duration `yaml:"token_reload_interval"`
 
+	// TokenListen applies token changes as they are committed, via LISTEN/NOTIFY, instead of
+	// only with the next periodic reload.
+	TokenListen bool `yaml:"token_listen"`
+
 	RateInterval           time.Duration `yaml:"rate_interval"`
 	EnableUserLimiter      bool          `yaml:"enable_user_limiter"`
 	UserLimit              int           `yaml:"user_limit"`
@@ -35,6 +49,17 @@ type LoggerConfig struct {
 	Compress   bool   `yaml:"compress"`
 }
 
+// TracingConfig controls OpenTelemetry span export. Incoming traceparent headers are
+// propagated even when export is disabled.
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Exporter    string  `yaml:"exporter"`     // "otlp" (OTLP over HTTP, default) or "stdout"
+	Endpoint    string  `yaml:"endpoint"`     // OTLP/HTTP collector host:port (default localhost:4318)
+	Insecure    bool    `yaml:"insecure"`     // plain HTTP instead of HTTPS
+	SampleRatio float64 `yaml:"sample_ratio"` // fraction of new traces to sample (default 1)
+	ServiceName string  `yaml:"service_name"` // default "auth-service"
+}
+
 // Load loads the configuration. You can override the path via CONFIG_PATH.
 func Load() Config {
 	path := os.Getenv("CONFIG_PATH")
@@ -8,4 +8,15 @@ var (
 	ErrTokenStoreNotReady = errors.New("token store not ready")
 	// ErrInvalidAPIKey is returned when an API key is provided but not found in the token store.
 	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrAPIKeyRevoked is returned for an API key whose revoked_at has passed.
+	ErrAPIKeyRevoked = errors.New("api key revoked")
+	// ErrAPIKeyExpired is returned for an API key whose expires_at has passed.
+	ErrAPIKeyExpired = errors.New("api key expired")
+	// ErrAPIKeyNotYetValid is returned for an API key whose not_before lies in the future.
+	ErrAPIKeyNotYetValid = errors.New("api key not yet valid")
+	// ErrTokenNotFound is returned by the admin API for a token ID that matches no token.
+	ErrTokenNotFound = errors.New("token not found")
+	// ErrAmbiguousTokenID is returned by the admin API for a shortened token ID that matches
+	// more than one token.
+	ErrAmbiguousTokenID = errors.New("ambiguous token id")
 )
@@ -33,4 +33,13 @@ func TestDomainErrors_AreStableAndUsableWithErrorsIs(t *testing.T) {
 	if got := ErrInvalidAPIKey.Error(); got == "" {
 		t.Fatalf("ErrInvalidAPIKey message should not be empty")
 	}
+
+	all := []error{ErrTokenStoreNotReady, ErrInvalidAPIKey, ErrAPIKeyRevoked, ErrAPIKeyExpired, ErrAPIKeyNotYetValid}
+	for i, a := range all {
+		for _, b := range all[i+1:] {
+			if errors.Is(a, b) {
+				t.Fatalf("domain errors must be distinct: %v, %v", a, b)
+			}
+		}
+	}
 }
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/infra/logging"
+	"auth-service/internal/tokens"
+)
+
+// TokenAdmin is the token storage behind the admin API (postgres.TokenRepository).
+type TokenAdmin interface {
+	ListTokens(ctx context.Context) ([]tokens.Record, error)
+	ResolveToken(ctx context.Context, id string) (tokens.Record, error)
+	CreateToken(ctx context.Context, rec tokens.Record) (tokens.Record, error)
+	UpdateToken(ctx context.Context, rec tokens.Record) error
+	RevokeToken(ctx context.Context, id string, at time.Time) error
+	RotateToken(ctx context.Context, id string, next tokens.Record, revokeAt time.Time) (tokens.Record, error)
+}
+
+// Admin serves the token lifecycle API. Every change is written to the repository and then
+// reloaded into this instance's token cache; other instances pick it up through the token change
+// feed (token_listen) or with their next reload.
+type Admin struct {
+	repo   TokenAdmin
+	reload func(ctx context.Context) error
+	now    func() time.Time
+}
+
+func NewAdmin(repo TokenAdmin, reload func(ctx context.Context) error) *Admin {
+	return &Admin{repo: repo, reload: reload, now: time.Now}
+}
+
+// Register mounts the admin routes on r. Tokens are addressed by ID, the token digest, or any
+// unique leading part of it of at least 8 characters.
+func (a *Admin) Register(r fiber.Router) {
+	r.Get("/tokens", a.list)
+	r.Post("/tokens", a.create)
+	r.Get("/tokens/:id", a.get)
+	r.Patch("/tokens/:id", a.update)
+	r.Post("/tokens/:id/rotate", a.rotate)
+	r.Post("/tokens/:id/revoke", a.revoke)
+}
+
+type createRequest struct {
+	RateLimit *int       `json:"rate_limit"`
+	Scopes    []string   `json:"scopes"`
+	Comment   string     `json:"comment"`
+	NotBefore *time.Time `json:"not_before"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// updateRequest changes only the fields present; not_before and expires_at are cleared by null.
+type updateRequest struct {
+	RateLimit *int         `json:"rate_limit"`
+	Scopes    *[]string    `json:"scopes"`
+	Comment   *string      `json:"comment"`
+	NotBefore optionalTime `json:"not_before"`
+	ExpiresAt optionalTime `json:"expires_at"`
+}
+
+type rotateRequest struct {
+	// GraceSecs keeps the old token valid for this long after rotation (default 0).
+	GraceSecs int `json:"grace_secs"`
+}
+
+type revokeRequest struct {
+	// At schedules the revocation; default now.
+	At *time.Time `json:"at"`
+}
+
+// optionalTime tells an absent JSON field from an explicit null.
+type optionalTime struct {
+	Set  bool
+	Time time.Time
+}
+
+func (o *optionalTime) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	if string(b) == "null" {
+		o.Time = time.Time{}
+		return nil
+	}
+	return json.Unmarshal(b, &o.Time)
+}
+
+func (a *Admin) list(c *fiber.Ctx) error {
+	recs, err := a.repo.ListTokens(c.UserContext())
+	if err != nil {
+		return a.fail(c, err)
+	}
+	views := make([]tokens.View, 0, len(recs))
+	for _, rec := range recs {
+		views = append(views, a.view(rec, ""))
+	}
+	return c.JSON(fiber.Map{"tokens": views})
+}
+
+func (a *Admin) get(c *fiber.Ctx) error {
+	rec, err := a.repo.ResolveToken(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return a.fail(c, err)
+	}
+	return c.JSON(a.view(rec, ""))
+}
+
+func (a *Admin) create(c *fiber.Ctx) error {
+	var req createRequest
+	if err := decodeBody(c, &req); err != nil {
+		return adminError(c, fiber.StatusBadRequest, err.Error())
+	}
+	rec := tokens.Record{RateLimit: tokens.DefaultRateLimit, Scope: tokens.Scope{"api": true}, Comment: req.Comment}
+	if req.RateLimit != nil {
+		rec.RateLimit = *req.RateLimit
+	}
+	if req.Scopes != nil {
+		rec.Scope = tokens.NewScope(req.Scopes)
+	}
+	if req.NotBefore != nil {
+		rec.NotBefore = *req.NotBefore
+	}
+	if req.ExpiresAt != nil {
+		rec.ExpiresAt = *req.ExpiresAt
+	}
+	if err := rec.Validate(); err != nil {
+		return adminError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	rec, secret, err := tokens.Issue(rec)
+	if err != nil {
+		return a.fail(c, err)
+	}
+	rec, err = a.repo.CreateToken(c.UserContext(), rec)
+	if err != nil {
+		return a.fail(c, err)
+	}
+	a.refresh(c, "created", rec.ID)
+	return c.Status(fiber.StatusCreated).JSON(a.view(rec, secret))
+}
+
+func (a *Admin) update(c *fiber.Ctx) error {
+	var req updateRequest
+	if err := decodeBody(c, &req); err != nil {
+		return adminError(c, fiber.StatusBadRequest, err.Error())
+	}
+	rec, err := a.repo.ResolveToken(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return a.fail(c, err)
+	}
+	if req.RateLimit != nil {
+		rec.RateLimit = *req.RateLimit
+	}
+	if req.Scopes != nil {
+		rec.Scope = tokens.NewScope(*req.Scopes)
+	}
+	if req.Comment != nil {
+		rec.Comment = *req.Comment
+	}
+	if req.NotBefore.Set {
+		rec.NotBefore = req.NotBefore.Time
+	}
+	if req.ExpiresAt.Set {
+		rec.ExpiresAt = req.ExpiresAt.Time
+	}
+	if err := rec.Validate(); err != nil {
+		return adminError(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err := a.repo.UpdateToken(c.UserContext(), rec); err != nil {
+		return a.fail(c, err)
+	}
+	a.refresh(c, "updated", rec.ID)
+	return c.JSON(a.view(rec, ""))
+}
+
+func (a *Admin) rotate(c *fiber.Ctx) error {
+	var req rotateRequest
+	if err := decodeBody(c, &req); err != nil {
+		return adminError(c, fiber.StatusBadRequest, err.Error())
+	}
+	if req.GraceSecs < 0 {
+		return adminError(c, fiber.StatusBadRequest, "grace_secs must be >= 0")
+	}
+	old, err := a.repo.ResolveToken(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return a.fail(c, err)
+	}
+	now := a.now()
+	if !old.RevokedAt.IsZero() && !now.Before(old.RevokedAt) {
+		return adminError(c, fiber.StatusConflict, "token is revoked")
+	}
+
+	next := old
+	next.CreatedAt, next.RevokedAt = time.Time{}, time.Time{}
+	next, secret, err := tokens.Issue(next)
+	if err != nil {
+		return a.fail(c, err)
+	}
+	next, err = a.repo.RotateToken(c.UserContext(), old.ID, next, now.Add(time.Duration(req.GraceSecs)*time.Second))
+	if err != nil {
+		return a.fail(c, err)
+	}
+	a.refresh(c, "rotated", old.ID)
+	return c.Status(fiber.StatusCreated).JSON(a.view(next, secret))
+}
+
+func (a *Admin) revoke(c *fiber.Ctx) error {
+	var req revokeRequest
+	if err := decodeBody(c, &req); err != nil {
+		return adminError(c, fiber.StatusBadRequest, err.Error())
+	}
+	rec, err := a.repo.ResolveToken(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return a.fail(c, err)
+	}
+	at := a.now()
+	if req.At != nil {
+		at = *req.At
+	}
+	if err := a.repo.RevokeToken(c.UserContext(), rec.ID, at); err != nil {
+		return a.fail(c, err)
+	}
+	if rec.RevokedAt.IsZero() || at.Before(rec.RevokedAt) {
+		rec.RevokedAt = at
+	}
+	a.refresh(c, "revoked", rec.ID)
+	return c.JSON(a.view(rec, ""))
+}
+
+// refresh reloads the token cache after a change. The change is already stored, so a failed
+// reload is only logged; the periodic reload catches up.
+func (a *Admin) refresh(c *fiber.Ctx, action, id string) {
+	key, _ := c.Locals("api_key").(string)
+	logging.Info("Admin token "+action, "id", id[:12], "by", tokens.Prefix(key))
+	if err := a.reload(c.UserContext()); err != nil {
+		logging.Error("Token reload after admin change failed", "error", err)
+	}
+}
+
+func (a *Admin) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrTokenNotFound):
+		return adminError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrAmbiguousTokenID):
+		return adminError(c, fiber.StatusConflict, err.Error())
+	}
+	logging.Error("Admin request failed", "error", err, "method", c.Method(), "path", c.Path())
+	return adminError(c, fiber.StatusInternalServerError, "internal error")
+}
+
+func (a *Admin) view(rec tokens.Record, secret string) tokens.View {
+	return rec.View(a.now(), secret)
+}
+
+// decodeBody decodes a JSON body into v; an empty body leaves v unchanged.
+func decodeBody(c *fiber.Ctx, v any) error {
+	if len(c.Body()) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(c.Body(), v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+func adminError(c *fiber.Ctx, status int, message string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"error": fiber.Map{
+			"code":    status,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/tokens"
+)
+
+type fakeTokenAdmin struct {
+	recs map[string]tokens.Record
+	now  time.Time
+}
+
+func (f *fakeTokenAdmin) ListTokens(ctx context.Context) ([]tokens.Record, error) {
+	var out []tokens.Record
+	for _, rec := range f.recs {
+		out = append(out, rec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (f *fakeTokenAdmin) ResolveToken(ctx context.Context, id string) (tokens.Record, error) {
+	var found []tokens.Record
+	for full, rec := range f.recs {
+		if strings.HasPrefix(full, id) {
+			found = append(found, rec)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return tokens.Record{}, domain.ErrTokenNotFound
+	case 1:
+		return found[0], nil
+	}
+	return tokens.Record{}, domain.ErrAmbiguousTokenID
+}
+
+func (f *fakeTokenAdmin) CreateToken(ctx context.Context, rec tokens.Record) (tokens.Record, error) {
+	rec.CreatedAt = f.now
+	f.recs[rec.ID] = rec
+	return rec, nil
+}
+
+func (f *fakeTokenAdmin) UpdateToken(ctx context.Context, rec tokens.Record) error {
+	f.recs[rec.ID] = rec
+	return nil
+}
+
+func (f *fakeTokenAdmin) RevokeToken(ctx context.Context, id string, at time.Time) error {
+	rec := f.recs[id]
+	if rec.RevokedAt.IsZero() || at.Before(rec.RevokedAt) {
+		rec.RevokedAt = at
+	}
+	f.recs[id] = rec
+	return nil
+}
+
+func (f *fakeTokenAdmin) RotateToken(ctx context.Context, id string, next tokens.Record, revokeAt time.Time) (tokens.Record, error) {
+	next, _ = f.CreateToken(ctx, next)
+	return next, f.RevokeToken(ctx, id, revokeAt)
+}
+
+func newAdminTestApp(t *testing.T) (*fiber.App, *fakeTokenAdmin, *tokens.Cache) {
+	t.Helper()
+	// The cache checks against the real clock, so the admin API has to as well.
+	now := time.Now()
+	repo := &fakeTokenAdmin{recs: map[string]tokens.Record{}, now: now}
+	cache := tokens.NewCache()
+	reload := func(ctx context.Context) error {
+		m := make(map[string]tokens.Entry, len(repo.recs))
+		for id, rec := range repo.recs {
+			m[id] = rec.Entry()
+		}
+		cache.Replace(m)
+		return nil
+	}
+	admin := NewAdmin(repo, reload)
+	admin.now = func() time.Time { return now }
+	app := fiber.New()
+	admin.Register(app.Group("/admin"))
+	return app, repo, cache
+}
+
+func adminDo(t *testing.T, app *fiber.App, method, target, body string, wantStatus int) tokens.View {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("%s %s: expected %d, got %d", method, target, wantStatus, resp.StatusCode)
+	}
+	var v tokens.View
+	_ = json.NewDecoder(resp.Body).Decode(&v)
+	return v
+}
+
+func TestAdmin_TokenLifecycle(t *testing.T) {
+	app, repo, cache := newAdminTestApp(t)
+
+	created := adminDo(t, app, http.MethodPost, "/admin/tokens", `{"rate_limit":5,"scopes":["api","inject"],"comment":"customer x"}`, fiber.StatusCreated)
+	if created.Token == "" || created.ID != tokens.Digest(created.Token) || created.Prefix != tokens.Prefix(created.Token) {
+		t.Fatalf("unexpected created token: %+v", created)
+	}
+	if created.Status != "active" || created.RateLimit != 5 || fmt.Sprint(created.Scopes) != "[api inject]" {
+		t.Fatalf("unexpected created token: %+v", created)
+	}
+	if cache.RateLimit(created.Token) != 5 {
+		t.Fatalf("expected the cache to be refreshed after create")
+	}
+	if _, stored := repo.recs[created.Token]; stored {
+		t.Fatalf("expected the secret not to be stored")
+	}
+
+	short := "/admin/tokens/" + created.ID[:8]
+	got := adminDo(t, app, http.MethodGet, short, "", fiber.StatusOK)
+	if got.Token != "" || got.ID != created.ID || got.Comment != "customer x" {
+		t.Fatalf("unexpected inspected token: %+v", got)
+	}
+
+	updated := adminDo(t, app, http.MethodPatch, short, `{"rate_limit":10,"expires_at":"2099-04-01T00:00:00Z"}`, fiber.StatusOK)
+	if updated.RateLimit != 10 || updated.ExpiresAt == nil || fmt.Sprint(updated.Scopes) != "[api inject]" {
+		t.Fatalf("unexpected updated token: %+v", updated)
+	}
+	cleared := adminDo(t, app, http.MethodPatch, short, `{"expires_at":null}`, fiber.StatusOK)
+	if cleared.ExpiresAt != nil || cleared.RateLimit != 10 {
+		t.Fatalf("expected null to clear expires_at only: %+v", cleared)
+	}
+
+	rotated := adminDo(t, app, http.MethodPost, short+"/rotate", `{"grace_secs":60}`, fiber.StatusCreated)
+	if rotated.Token == "" || rotated.Token == created.Token || rotated.RateLimit != 10 {
+		t.Fatalf("unexpected rotated token: %+v", rotated)
+	}
+	if !cache.Validate(created.Token) || !cache.Validate(rotated.Token) {
+		t.Fatalf("expected both tokens to be valid during the grace period")
+	}
+	if old := repo.recs[created.ID]; !old.RevokedAt.Equal(repo.now.Add(time.Minute)) {
+		t.Fatalf("expected the old token to be revoked after the grace period, got %v", old.RevokedAt)
+	}
+
+	revoked := adminDo(t, app, http.MethodPost, "/admin/tokens/"+rotated.ID+"/revoke", "", fiber.StatusOK)
+	if revoked.Status != "revoked" {
+		t.Fatalf("expected revoked status, got %+v", revoked)
+	}
+	if !errors.Is(cache.Check(rotated.Token), domain.ErrAPIKeyRevoked) {
+		t.Fatalf("expected the cache to reject the revoked token")
+	}
+	adminDo(t, app, http.MethodPost, "/admin/tokens/"+rotated.ID+"/rotate", "", fiber.StatusConflict)
+}
+
+func TestAdmin_Errors(t *testing.T) {
+	app, repo, _ := newAdminTestApp(t)
+	repo.recs["aaaaaaaa11"] = tokens.Record{ID: "aaaaaaaa11"}
+	repo.recs["aaaaaaaa22"] = tokens.Record{ID: "aaaaaaaa22"}
+
+	adminDo(t, app, http.MethodGet, "/admin/tokens/aaaaaaaa", "", fiber.StatusConflict)
+	adminDo(t, app, http.MethodGet, "/admin/tokens/bbbbbbbb", "", fiber.StatusNotFound)
+	adminDo(t, app, http.MethodPost, "/admin/tokens", `{"rate_limit":-1}`, fiber.StatusBadRequest)
+	adminDo(t, app, http.MethodPost, "/admin/tokens", `{"scopes":["api,ops"]}`, fiber.StatusBadRequest)
+	adminDo(t, app, http.MethodPost, "/admin/tokens", `{"not_before":"2026-05-01T00:00:00Z","expires_at":"2026-04-01T00:00:00Z"}`, fiber.StatusBadRequest)
+	adminDo(t, app, http.MethodPost, "/admin/tokens", `{`, fiber.StatusBadRequest)
+	adminDo(t, app, http.MethodPost, "/admin/tokens/aaaaaaaa11/rotate", `{"grace_secs":-1}`, fiber.StatusBadRequest)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/admin/tokens", nil))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var list struct {
+		Tokens []tokens.View `json:"tokens"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil || len(list.Tokens) != 2 {
+		t.Fatalf("expected two listed tokens, got %+v (%v)", list, err)
+	}
+}
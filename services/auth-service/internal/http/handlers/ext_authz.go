@@ -1,12 +1,40 @@
 package handlers
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"auth-service/internal/infra/metrics"
+	"auth-service/internal/tokens"
+)
 
 func ExtAuthzOK(c *fiber.Ctx) error {
 	mode := "public"
-	if token, ok := c.Locals("api_key").(string); ok && token != "" {
+	token, ok := c.Locals("api_key").(string)
+	if ok && token != "" {
 		mode = "token"
 	}
 	c.Set("X-Auth-Mode", mode)
+	metrics.Decisions.WithLabelValues("allow", mode).Inc()
+	trace.SpanFromContext(c.UserContext()).SetAttributes(attribute.String("authz.mode", mode))
+	c.Set("X-Auth-Subject", authSubject(c, token))
+	// Always set, so Envoy overwrites any X-Auth-Scopes sent by the client.
+	scopes, _ := c.Locals("scopes").([]string)
+	c.Set("X-Auth-Scopes", strings.Join(scopes, ","))
 	return c.SendStatus(fiber.StatusOK)
 }
+
+// authSubject identifies the caller for upstream fair scheduling without exposing the key:
+// a digest prefix of the API key, or the client address for public requests.
+func authSubject(c *fiber.Ctx, token string) string {
+	if token != "" {
+		return "key:" + tokens.Digest(token)[:16]
+	}
+	if addr := c.Get("X-Envoy-External-Address"); addr != "" {
+		return "ip:" + addr
+	}
+	return "ip:" + c.IP()
+}
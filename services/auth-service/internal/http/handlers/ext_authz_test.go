@@ -12,6 +12,7 @@ func TestExtAuthzOK_PublicAndTokenModes(t *testing.T) {
 	app.Get("/public", ExtAuthzOK)
 	app.Get("/token", func(c *fiber.Ctx) error {
 		c.Locals("api_key", "abc")
+		c.Locals("scopes", []string{"api", "ops"})
 		return ExtAuthzOK(c)
 	})
 
@@ -23,6 +24,12 @@ func TestExtAuthzOK_PublicAndTokenModes(t *testing.T) {
 	if got := resp1.Header.Get("X-Auth-Mode"); got != "public" {
 		t.Fatalf("expected public mode, got %q", got)
 	}
+	if got := resp1.Header.Get("X-Auth-Subject"); got != "ip:0.0.0.0" {
+		t.Fatalf("expected client address subject, got %q", got)
+	}
+	if got, ok := resp1.Header["X-Auth-Scopes"]; !ok || got[0] != "" {
+		t.Fatalf("expected empty scopes header for public requests, got %v", got)
+	}
 
 	req2, _ := http.NewRequest(http.MethodGet, "/token", nil)
 	resp2, err := app.Test(req2)
@@ -32,4 +39,21 @@ func TestExtAuthzOK_PublicAndTokenModes(t *testing.T) {
 	if got := resp2.Header.Get("X-Auth-Mode"); got != "token" {
 		t.Fatalf("expected token mode, got %q", got)
 	}
+	// sha256("abc") = ba7816bf8f01cfea...
+	if got := resp2.Header.Get("X-Auth-Subject"); got != "key:ba7816bf8f01cfea" {
+		t.Fatalf("expected key digest subject, got %q", got)
+	}
+	if got := resp2.Header.Get("X-Auth-Scopes"); got != "api,ops" {
+		t.Fatalf("expected token scopes, got %q", got)
+	}
+
+	req3, _ := http.NewRequest(http.MethodGet, "/public", nil)
+	req3.Header.Set("X-Envoy-External-Address", "203.0.113.7")
+	resp3, err := app.Test(req3)
+	if err != nil {
+		t.Fatalf("public request failed: %v", err)
+	}
+	if got := resp3.Header.Get("X-Auth-Subject"); got != "ip:203.0.113.7" {
+		t.Fatalf("expected external address subject, got %q", got)
+	}
 }
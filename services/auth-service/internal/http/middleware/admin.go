@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/infra/logging"
+)
+
+// AdminScope is the token scope required by the admin API.
+const AdminScope = "admin"
+
+// AdminAuth requires an X-API-Key with the admin scope. Unlike OptionalAPIKeyAuth there is no
+// public access.
+func AdminAuth(tokens TokenStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get("X-API-Key"))
+		var err error
+		status := fiber.StatusUnauthorized
+		switch {
+		case key == "":
+			err = errors.New("missing api key")
+		case !tokens.Ready():
+			err, status = domain.ErrTokenStoreNotReady, fiber.StatusServiceUnavailable
+		default:
+			if err = tokens.Check(key); err == nil && !tokens.HasScope(key, AdminScope) {
+				err, status = errors.New("admin scope required"), fiber.StatusForbidden
+			}
+		}
+		if err != nil {
+			logging.Warn("Admin reject", "reason", err.Error(), "key", redactToken(key), "method", c.Method(), "path", c.Path())
+			return c.Status(status).JSON(fiber.Map{
+				"error": fiber.Map{
+					"code":    status,
+					"message": err.Error(),
+				},
+			})
+		}
+		c.Locals("api_key", key)
+		return c.Next()
+	}
+}
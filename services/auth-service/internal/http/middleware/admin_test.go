@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth-service/internal/tokens"
+)
+
+func TestAdminAuth(t *testing.T) {
+	cache := tokens.NewCache()
+	app := fiber.New()
+	app.Use(AdminAuth(cache))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	check := func(key string, want int) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != want {
+			t.Fatalf("key %q: expected %d, got %d", key, want, resp.StatusCode)
+		}
+	}
+
+	check("admin-key-0001", fiber.StatusServiceUnavailable)
+
+	cache.Replace(hashed(map[string]tokens.Entry{
+		"admin-key-0001": {Scope: tokens.Scope{"admin": true}},
+		"admin-key-0002": {Scope: tokens.Scope{"admin": true}, RevokedAt: time.Now().Add(-time.Minute)},
+		"api-key-000001": {Scope: tokens.Scope{"api": true, "ops": true}},
+	}))
+	check("", fiber.StatusUnauthorized)
+	check("unknown-key-01", fiber.StatusUnauthorized)
+	check("admin-key-0002", fiber.StatusUnauthorized)
+	check("api-key-000001", fiber.StatusForbidden)
+	check("admin-key-0001", fiber.StatusOK)
+}
@@ -6,15 +6,19 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/keyauth"
+	"go.opentelemetry.io/otel/attribute"
 
 	"auth-service/internal/domain"
 	"auth-service/internal/infra/logging"
+	"auth-service/internal/infra/metrics"
+	"auth-service/internal/infra/tracing"
 )
 
 type TokenStore interface {
 	Ready() bool
-	Validate(token string) bool
+	Check(token string) error
 	HasScope(token, scope string) bool
+	Scopes(token string) []string
 }
 
 func OptionalAPIKeyAuth(tokens TokenStore) fiber.Handler {
@@ -22,22 +26,38 @@ func OptionalAPIKeyAuth(tokens TokenStore) fiber.Handler {
 		KeyLookup:  "header:X-API-Key",
 		ContextKey: "api_key",
 		Validator: func(c *fiber.Ctx, key string) (bool, error) {
+			_, span := tracing.Start(c.UserContext(), "token_cache.lookup")
+			result := "valid"
+			defer func() {
+				span.SetAttributes(attribute.String("authz.result", result))
+				span.End()
+			}()
+
 			trimmed := strings.TrimSpace(key)
 			if trimmed != key {
 				key = trimmed
 			}
 			if !tokens.Ready() {
 				logging.Warn("Auth reject", "reason", "token_store_not_ready", "method", c.Method(), "path", c.Path())
+				metrics.Decisions.WithLabelValues("deny", "token_store_not_ready").Inc()
+				result = "token_store_not_ready"
 				return false, domain.ErrTokenStoreNotReady
 			}
-			if !tokens.Validate(key) {
-				logging.Warn("Auth reject", "reason", "invalid_key", "key", redactToken(key), "method", c.Method(), "path", c.Path())
-				return false, domain.ErrInvalidAPIKey
+			if err := tokens.Check(key); err != nil {
+				reason := denyReason(err)
+				logging.Warn("Auth reject", "reason", reason, "key", redactToken(key), "method", c.Method(), "path", c.Path())
+				metrics.Decisions.WithLabelValues("deny", reason).Inc()
+				result = reason
+				return false, err
 			}
 			if isOpsPathFromRequest(c.Path()) && !tokens.HasScope(key, "ops") {
 				logging.Warn("Auth reject", "reason", "missing_ops_scope", "key", redactToken(key), "method", c.Method(), "path", c.Path())
+				metrics.Decisions.WithLabelValues("deny", "missing_ops_scope").Inc()
+				result = "missing_ops_scope"
 				return false, domain.ErrInvalidAPIKey
 			}
+			// Forwarded upstream by ExtAuthzOK (X-Auth-Scopes).
+			c.Locals("scopes", tokens.Scopes(key))
 			logging.Info("Auth allow", "key", redactToken(key), "method", c.Method(), "path", c.Path())
 			return true, nil
 		},
@@ -60,10 +80,15 @@ func OptionalAPIKeyAuth(tokens TokenStore) fiber.Handler {
 			if errors.Is(err, domain.ErrTokenStoreNotReady) {
 				status = fiber.StatusServiceUnavailable
 			}
+			// Missing keys on /ops never reach the Validator, which counts every other denial.
+			if errors.Is(err, keyauth.ErrMissingOrMalformedAPIKey) {
+				metrics.Decisions.WithLabelValues("deny", "missing_key").Inc()
+			}
 			logging.Warn("Auth error", "status", status, "message", err.Error(), "method", c.Method(), "path", c.Path())
 			return c.Status(status).JSON(fiber.Map{
 				"error": fiber.Map{
 					"code":    status,
+					"reason":  denyReason(err),
 					"message": err.Error(),
 				},
 			})
@@ -71,6 +96,26 @@ func OptionalAPIKeyAuth(tokens TokenStore) fiber.Handler {
 	})
 }
 
+// denyReason maps an authentication error to the reason reported in logs, metrics and the
+// error response.
+func denyReason(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrTokenStoreNotReady):
+		return "token_store_not_ready"
+	case errors.Is(err, domain.ErrAPIKeyRevoked):
+		return "revoked"
+	case errors.Is(err, domain.ErrAPIKeyExpired):
+		return "expired"
+	case errors.Is(err, domain.ErrAPIKeyNotYetValid):
+		return "not_yet_valid"
+	case errors.Is(err, domain.ErrInvalidAPIKey):
+		return "invalid_key"
+	case errors.Is(err, keyauth.ErrMissingOrMalformedAPIKey):
+		return "missing_key"
+	}
+	return "unauthorized"
+}
+
 func redactToken(token string) string {
 	if token == "" {
 		return ""
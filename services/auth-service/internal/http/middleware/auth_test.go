@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
+	"auth-service/internal/infra/metrics"
 	"auth-service/internal/tokens"
 	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestOptionalAPIKeyAuth_PublicAccess(t *testing.T) {
@@ -46,12 +50,12 @@ func TestOptionalAPIKeyAuth_TokenStoreNotReady(t *testing.T) {
 func TestOptionalAPIKeyAuth_InvalidAndValidKey(t *testing.T) {
 	app := fiber.New()
 	cache := tokens.NewCache()
-	cache.Replace(map[string]tokens.Entry{
+	cache.Replace(hashed(map[string]tokens.Entry{
 		"good": {
 			RateLimit: 1,
 			Scope:     tokens.Scope{"api": true},
 		},
-	})
+	}))
 
 	app.Use(OptionalAPIKeyAuth(cache))
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -59,6 +63,9 @@ func TestOptionalAPIKeyAuth_InvalidAndValidKey(t *testing.T) {
 		if v, _ := c.Locals("api_key").(string); v == "" {
 			return c.Status(fiber.StatusInternalServerError).SendString("missing api_key local")
 		}
+		if v, _ := c.Locals("scopes").([]string); len(v) != 1 || v[0] != "api" {
+			return c.Status(fiber.StatusInternalServerError).SendString("missing scopes local")
+		}
 		return c.SendStatus(fiber.StatusOK)
 	})
 
@@ -84,3 +91,78 @@ func TestOptionalAPIKeyAuth_InvalidAndValidKey(t *testing.T) {
 		t.Fatalf("expected 200, got %d", resp2.StatusCode)
 	}
 }
+
+func TestOptionalAPIKeyAuth_DenyReasons(t *testing.T) {
+	app := fiber.New()
+	cache := tokens.NewCache()
+	cache.Replace(hashed(map[string]tokens.Entry{
+		"expired-key": {Scope: tokens.Scope{"api": true}, ExpiresAt: time.Now().Add(-time.Minute)},
+		"revoked-key": {Scope: tokens.Scope{"api": true}, RevokedAt: time.Now().Add(-time.Minute)},
+		"future-key":  {Scope: tokens.Scope{"api": true}, NotBefore: time.Now().Add(time.Hour)},
+	}))
+
+	app.Use(OptionalAPIKeyAuth(cache))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := map[string]string{
+		"expired-key": "expired",
+		"revoked-key": "revoked",
+		"future-key":  "not_yet_valid",
+		"unknown-key": "invalid_key",
+	}
+	for key, want := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Fatalf("%s: expected 401, got %d", key, resp.StatusCode)
+		}
+		var body struct {
+			Error struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("%s: decode body: %v", key, err)
+		}
+		if body.Error.Reason != want {
+			t.Fatalf("%s: expected reason %q, got %q", key, want, body.Error.Reason)
+		}
+	}
+}
+
+func TestOptionalAPIKeyAuth_MissingKeyOnOpsIsCounted(t *testing.T) {
+	app := fiber.New()
+	cache := tokens.NewCache()
+	cache.Replace(map[string]tokens.Entry{})
+
+	app.Use(OptionalAPIKeyAuth(cache))
+	app.Get("/ops/stats", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	missing := metrics.Decisions.WithLabelValues("deny", "missing_key")
+	before := testutil.ToFloat64(missing)
+	req, _ := http.NewRequest(http.MethodGet, "/ops/stats", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	if got := testutil.ToFloat64(missing) - before; got != 1 {
+		t.Fatalf("expected 1 missing_key denial, got %v", got)
+	}
+}
+
+// hashed keys entries by token digest, the way the repository loads them.
+func hashed(byToken map[string]tokens.Entry) map[string]tokens.Entry {
+	out := make(map[string]tokens.Entry, len(byToken))
+	for token, entry := range byToken {
+		entry.Prefix = tokens.Prefix(token)
+		out[tokens.Digest(token)] = entry
+	}
+	return out
+}
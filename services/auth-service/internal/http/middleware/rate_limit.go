@@ -8,8 +8,12 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"go.opentelemetry.io/otel/attribute"
 
 	"auth-service/internal/infra/logging"
+	"auth-service/internal/infra/metrics"
+	"auth-service/internal/infra/tracing"
+	"auth-service/internal/tokens"
 )
 
 type TokenRater interface {
@@ -45,15 +49,18 @@ func (lc *LimiterCache) GetOrCreate(max int, interval time.Duration, store fiber
 		Expiration:        interval,
 		LimiterMiddleware: limiter.SlidingWindow{},
 		Storage:           store,
+		// Keyed by digest so the shared limiter storage never holds API keys.
 		KeyGenerator: func(c *fiber.Ctx) string {
 			if token, ok := c.Locals("api_key").(string); ok {
-				return token
+				return tokens.Digest(token)
 			}
 			return ""
 		},
 		LimitReached: func(c *fiber.Ctx) error {
 			token, _ := c.Locals("api_key").(string)
-			logging.Warn("Rate limit exceeded", "token", token, "path", c.Path())
+			logging.Warn("Rate limit exceeded", "token", redactToken(token), "path", c.Path())
+			metrics.RateLimitRejections.WithLabelValues("token").Inc()
+			metrics.Decisions.WithLabelValues("deny", "rate_limited").Inc()
 			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
 				"error": fiber.Map{
 					"code":    fiber.StatusTooManyRequests,
@@ -85,7 +92,7 @@ func TokenRateLimit(cfg RateLimitConfig, tokens TokenRater, store fiber.Storage,
 		if limit == 0 {
 			return c.Next()
 		}
-		return cache.GetOrCreate(limit, cfg.RateInterval, store)(c)
+		return traceLimiter(c, "rate_limit.token", limit, cache.GetOrCreate(limit, cfg.RateInterval, store))
 	}
 }
 
@@ -107,6 +114,8 @@ func UserRateLimit(cfg RateLimitConfig, store fiber.Storage) fiber.Handler {
 			sum := sha256.Sum256([]byte(c.IP() + c.Get("User-Agent")))
 			key := hex.EncodeToString(sum[:])
 			logging.Warn("Rate limit exceeded", "user", key, "path", c.Path())
+			metrics.RateLimitRejections.WithLabelValues("user").Inc()
+			metrics.Decisions.WithLabelValues("deny", "rate_limited").Inc()
 			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
 				"error": fiber.Map{
 					"code":    fiber.StatusTooManyRequests,
@@ -124,6 +133,16 @@ func UserRateLimit(cfg RateLimitConfig, store fiber.Storage) fiber.Handler {
 		if token, ok := c.Locals("api_key").(string); ok && token != "" {
 			return c.Next()
 		}
-		return userLimiter(c)
+		return traceLimiter(c, "rate_limit.user", cfg.UserLimit, userLimiter)
 	}
 }
+
+// traceLimiter runs a limiter inside a span. The limiter calls the rest of the chain when the
+// request is allowed, so the span also covers the (trivial) allow handler.
+func traceLimiter(c *fiber.Ctx, name string, max int, h fiber.Handler) error {
+	_, span := tracing.Start(c.UserContext(), name, attribute.Int("rate_limit.max", max))
+	err := h(c)
+	span.SetAttributes(attribute.Bool("rate_limit.rejected", c.Response().StatusCode() == fiber.StatusTooManyRequests))
+	tracing.End(span, err)
+	return err
+}
@@ -10,7 +10,9 @@ import (
 	"auth-service/internal/http/handlers"
 	"auth-service/internal/http/middleware"
 	"auth-service/internal/infra/logging"
+	"auth-service/internal/infra/metrics"
 	"auth-service/internal/infra/ratelimit"
+	"auth-service/internal/infra/tracing"
 	"auth-service/internal/tokens"
 )
 
@@ -18,6 +20,10 @@ type Deps struct {
 	Config     config.Config
 	TokenCache *tokens.Cache
 	Store      fiber.Storage
+
+	// Admin API (admin_listen_addr): token storage and the reloader refreshing TokenCache.
+	TokenAdmin handlers.TokenAdmin
+	Reloader   *tokens.Reloader
 }
 
 func NewApp(deps Deps) *fiber.App {
@@ -32,6 +38,9 @@ func NewApp(deps Deps) *fiber.App {
 
 	app.Use(healthcheck.New())
 
+	// After the health check so its probes do not produce traces.
+	app.Use(tracing.Middleware())
+
 	// API key auth (optional). Missing key = public access.
 	app.Use(middleware.OptionalAPIKeyAuth(deps.TokenCache))
 
@@ -55,8 +64,43 @@ func NewApp(deps Deps) *fiber.App {
 	return app
 }
 
+// NewMetricsApp serves Prometheus metrics. It runs on its own listener so metrics are not
+// reachable through the ext-authz path.
+func NewMetricsApp() *fiber.App {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/metrics", metrics.Handler())
+	return app
+}
+
+// NewAdminApp serves the token admin API under /admin. Like metrics it runs on its own listener
+// and is not routed through Envoy.
+func NewAdminApp(deps Deps) *fiber.App {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	admin := app.Group("/admin", middleware.AdminAuth(deps.TokenCache))
+	handlers.NewAdmin(deps.TokenAdmin, deps.Reloader.LoadOnce).Register(admin)
+	return app
+}
+
 func Run(ctx context.Context, deps Deps) error {
 	_ = ctx
+	if addr := deps.Config.MetricsListenAddr; addr != "" {
+		go func() {
+			logging.Info("Auth service metrics listening", "addr", addr)
+			if err := NewMetricsApp().Listen(addr); err != nil {
+				logging.Error("Metrics listener stopped", "error", err)
+			}
+		}()
+	}
+
+	if addr := deps.Config.AdminListenAddr; addr != "" {
+		go func() {
+			logging.Info("Auth service admin API listening", "addr", addr)
+			if err := NewAdminApp(deps).Listen(addr); err != nil {
+				logging.Error("Admin listener stopped", "error", err)
+			}
+		}()
+	}
+
 	app := NewApp(deps)
 	logging.Info("Auth service listening", "addr", deps.Config.ListenAddr)
 	return app.Listen(deps.Config.ListenAddr)
@@ -1,12 +1,19 @@
 package server
 
 import (
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	memoryStorage "github.com/gofiber/storage/memory/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
 	"auth-service/internal/config"
 	"auth-service/internal/tokens"
@@ -14,7 +21,7 @@ import (
 
 func TestNewApp_ExtAuthzModesAndAuth(t *testing.T) {
 	cache := tokens.NewCache()
-	cache.Replace(map[string]tokens.Entry{
+	cache.Replace(hashed(map[string]tokens.Entry{
 		"good-get": {
 			RateLimit: 1,
 			Scope:     tokens.Scope{"api": true},
@@ -27,7 +34,7 @@ func TestNewApp_ExtAuthzModesAndAuth(t *testing.T) {
 			RateLimit: 1,
 			Scope:     tokens.Scope{"api": true, "ops": true},
 		},
-	})
+	}))
 
 	deps := Deps{
 		Config: config.Config{
@@ -121,3 +128,85 @@ func TestNewApp_ExtAuthzModesAndAuth(t *testing.T) {
 	assertOpsAuth("good-get", fiber.StatusUnauthorized)
 	assertOpsAuth("ops-only", fiber.StatusOK)
 }
+
+func TestNewMetricsApp_ExposesDecisions(t *testing.T) {
+	cache := tokens.NewCache()
+	cache.Replace(map[string]tokens.Entry{})
+	app := NewApp(Deps{
+		Config:     config.Config{ListenAddr: ":0", RateInterval: time.Hour},
+		TokenCache: cache,
+		Store:      memoryStorage.New(),
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/ext-authz/test", nil)
+	req.Header.Set("X-API-Key", "unknown")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	resp, err := NewMetricsApp().Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if err != nil {
+		t.Fatalf("metrics request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	want := `auth_service_ext_authz_decisions_total{decision="deny",reason="invalid_key"}`
+	if !strings.Contains(string(body), want) {
+		t.Fatalf("expected %s in metrics output", want)
+	}
+}
+
+func TestNewApp_TracesCheckSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	cache := tokens.NewCache()
+	cache.Replace(hashed(map[string]tokens.Entry{
+		"good": {RateLimit: 5, Scope: tokens.Scope{"api": true}},
+	}))
+	app := NewApp(Deps{
+		Config: config.Config{
+			ListenAddr:             ":0",
+			RateInterval:           time.Hour,
+			EnableTokenRateLimiter: true,
+		},
+		TokenCache: cache,
+		Store:      memoryStorage.New(),
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/ext-authz/v0/pdf", nil)
+	req.Header.Set("X-API-Key", "good")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var names []string
+	for _, s := range rec.Ended() {
+		if got := s.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("span %q not in the incoming trace: %s", s.Name(), got)
+		}
+		names = append(names, s.Name())
+	}
+	if got := strings.Join(names, ","); got != "token_cache.lookup,rate_limit.token,GET /ext-authz/*" {
+		t.Fatalf("unexpected spans %q", got)
+	}
+}
+
+// hashed keys entries by token digest, the way the repository loads them.
+func hashed(byToken map[string]tokens.Entry) map[string]tokens.Entry {
+	out := make(map[string]tokens.Entry, len(byToken))
+	for token, entry := range byToken {
+		entry.Prefix = tokens.Prefix(token)
+		out[tokens.Digest(token)] = entry
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+// Package metrics defines the Prometheus time series exported by the auth-service on its
+// dedicated metrics listener.
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "auth_service"
+
+// Registry holds every auth-service metric plus the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// Decisions counts ext_authz outcomes: allow (reason public|token) or deny (reason
+	// invalid_key|missing_key|expired|revoked|not_yet_valid|missing_ops_scope|token_store_not_ready|
+	// rate_limited).
+	Decisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ext_authz_decisions_total",
+		Help:      "ext_authz decisions by outcome and reason.",
+	}, []string{"decision", "reason"})
+
+	// RateLimitRejections counts requests rejected by a limiter (token|user).
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected by rate limiting, by limiter type.",
+	}, []string{"limiter"})
+
+	// TokenCacheSize is the number of tokens in the in-memory cache.
+	TokenCacheSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "token_cache_size",
+		Help:      "Tokens currently loaded in the cache.",
+	})
+
+	// TokenLastReload is the Unix time of the last successful token reload.
+	TokenLastReload = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "token_last_reload_timestamp_seconds",
+		Help:      "Unix time of the last successful token reload.",
+	})
+
+	// TokenLoadDuration measures token loads from Postgres.
+	TokenLoadDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "token_load_duration_seconds",
+		Help:      "Duration of token loads from Postgres.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	})
+
+	// TokenLoadErrors counts failed token loads from Postgres.
+	TokenLoadErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_load_errors_total",
+		Help:      "Failed token loads from Postgres.",
+	})
+
+	// TokenChanges counts token changes applied from the change feed by op (upsert|delete|reset).
+	TokenChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_changes_total",
+		Help:      "Token changes applied from the Postgres change feed, by operation.",
+	}, []string{"op"})
+
+	// StorageErrors counts rate-limit storage (Redis) errors by operation.
+	StorageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_errors_total",
+		Help:      "Rate-limit storage errors by operation.",
+	}, []string{"op"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Decisions,
+		RateLimitRejections,
+		TokenCacheSize,
+		TokenLastReload,
+		TokenLoadDuration,
+		TokenLoadErrors,
+		TokenChanges,
+		StorageErrors,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"time"
+)
+
+// baselineMigration creates the tokens table. It is not re-runnable, so databases migrated
+// before schema_migrations existed record it as applied instead of running it again.
+const baselineMigration = "001_create_tokens_table.sql"
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    name TEXT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`
+
+// Migrate applies the *.sql files in fsys that are not recorded in schema_migrations yet, in name
+// order, and returns the names applied. Each one runs in a transaction that also records it, so
+// a failed migration leaves nothing behind and is retried next time; the files must therefore not
+// contain BEGIN or COMMIT themselves.
+func Migrate(ctx context.Context, db *sql.DB, fsys fs.FS) ([]string, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	done, err := appliedMigrations(ctx, db, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, name := range names {
+		if done[name] {
+			continue
+		}
+		script, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return applied, err
+		}
+		ok, err := applyMigration(ctx, db, name, string(script))
+		if err != nil {
+			return applied, fmt.Errorf("migration %s: %w", name, err)
+		}
+		if ok {
+			applied = append(applied, name)
+		}
+	}
+	return applied, nil
+}
+
+// appliedMigrations creates schema_migrations if needed and returns the migrations recorded in it.
+// When nothing is recorded but the tokens table exists, the database was migrated by hand (or by
+// an earlier authctl): the baseline is recorded and the later, idempotent migrations run once more.
+func appliedMigrations(ctx context.Context, db *sql.DB, names []string) (map[string]bool, error) {
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(cctx, createMigrationsTable); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+	rows, err := db.QueryContext(cctx, `SELECT name FROM schema_migrations;`)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	done := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("read schema_migrations: %w", err)
+		}
+		done[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+
+	if len(done) == 0 && slices.Contains(names, baselineMigration) {
+		var exists bool
+		if err := db.QueryRowContext(cctx, `SELECT to_regclass('public.tokens') IS NOT NULL;`).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("check tokens table: %w", err)
+		}
+		if exists {
+			if _, err := db.ExecContext(cctx, `INSERT INTO schema_migrations (name) VALUES ($1) ON CONFLICT DO NOTHING;`, baselineMigration); err != nil {
+				return nil, fmt.Errorf("record %s: %w", baselineMigration, err)
+			}
+			done[baselineMigration] = true
+		}
+	}
+	return done, nil
+}
+
+// applyMigration runs script and records it in one transaction. It reports false when another
+// authctl recorded the migration in the meantime; the row lock makes that one wait for ours.
+func applyMigration(ctx context.Context, db *sql.DB, name, script string) (bool, error) {
+	cctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	tx, err := db.BeginTx(cctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(cctx, `INSERT INTO schema_migrations (name) VALUES ($1) ON CONFLICT DO NOTHING;`, name)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	// Without arguments the script runs over the simple protocol, which allows several statements.
+	if _, err := tx.ExecContext(cctx, script); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// migrateDB is an in-memory stand-in for Postgres that understands the schema_migrations
+// statements of Migrate, applies recorded names only on commit and logs every other statement.
+type migrateDB struct {
+	mu          sync.Mutex
+	recorded    map[string]bool
+	execs       []string
+	failOn      string
+	tokensTable bool
+}
+
+type migrateConn struct {
+	db      *migrateDB
+	inTx    bool
+	pending []string
+}
+
+type migrateTx struct{ c *migrateConn }
+
+func (d *migrateDB) Connect(context.Context) (driver.Conn, error) { return &migrateConn{db: d}, nil }
+func (d *migrateDB) Driver() driver.Driver                        { return nil }
+
+func (d *migrateDB) names() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var names []string
+	for name := range d.recorded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *migrateConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (c *migrateConn) Close() error                        { return nil }
+func (c *migrateConn) Begin() (driver.Tx, error) {
+	c.inTx, c.pending = true, nil
+	return migrateTx{c}, nil
+}
+
+func (t migrateTx) Commit() error {
+	t.c.db.mu.Lock()
+	defer t.c.db.mu.Unlock()
+	for _, name := range t.c.pending {
+		t.c.db.recorded[name] = true
+	}
+	t.c.inTx, t.c.pending = false, nil
+	return nil
+}
+
+func (t migrateTx) Rollback() error {
+	t.c.inTx, t.c.pending = false, nil
+	return nil
+}
+
+func (c *migrateConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		name := args[0].Value.(string)
+		if c.db.recorded[name] || slices.Contains(c.pending, name) {
+			return driver.RowsAffected(0), nil
+		}
+		if c.inTx {
+			c.pending = append(c.pending, name)
+		} else {
+			c.db.recorded[name] = true
+		}
+		return driver.RowsAffected(1), nil
+	}
+	if c.db.failOn != "" && strings.Contains(query, c.db.failOn) {
+		return nil, errors.New("exec failed")
+	}
+	c.db.execs = append(c.db.execs, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *migrateConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	switch {
+	case strings.Contains(query, "FROM schema_migrations"):
+		rows := &fakeRows{cols: []string{"name"}}
+		for name := range c.db.recorded {
+			rows.data = append(rows.data, []driver.Value{name})
+		}
+		return rows, nil
+	case strings.Contains(query, "to_regclass('public.tokens')"):
+		return &fakeRows{cols: []string{"exists"}, data: [][]driver.Value{{c.db.tokensTable}}}, nil
+	}
+	return nil, errors.New("unexpected query " + query)
+}
+
+func openMigrateDB(t *testing.T, state *migrateDB) *sql.DB {
+	t.Helper()
+	state.recorded = map[string]bool{}
+	db := sql.OpenDB(state)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestMigrate_AppliesFilesInOrderOnce(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_second.sql": {Data: []byte("SELECT 2;")},
+		"001_first.sql":  {Data: []byte("SELECT 1;")},
+		"README.md":      {Data: []byte("not a migration")},
+	}
+	state := &migrateDB{}
+	db := openMigrateDB(t, state)
+
+	applied, err := Migrate(context.Background(), db, fsys)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if strings.Join(applied, ",") != "001_first.sql,002_second.sql" {
+		t.Fatalf("unexpected applied migrations %v", applied)
+	}
+	if strings.Join(state.execs, " ") != "SELECT 1; SELECT 2;" {
+		t.Fatalf("unexpected statements %v", state.execs)
+	}
+
+	applied, err = Migrate(context.Background(), db, fsys)
+	if err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	if len(applied) != 0 || len(state.execs) != 2 {
+		t.Fatalf("expected the second run to be a no-op, applied %v, statements %v", applied, state.execs)
+	}
+}
+
+func TestMigrate_FailedMigrationIsNotRecorded(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_first.sql":  {Data: []byte("SELECT 1;")},
+		"002_second.sql": {Data: []byte("SELECT 2;")},
+	}
+	state := &migrateDB{failOn: "SELECT 2"}
+	db := openMigrateDB(t, state)
+
+	applied, err := Migrate(context.Background(), db, fsys)
+	if err == nil || !strings.Contains(err.Error(), "002_second.sql") {
+		t.Fatalf("expected the failing migration to be named, got %v", err)
+	}
+	if len(applied) != 1 || strings.Join(state.names(), ",") != "001_first.sql" {
+		t.Fatalf("expected only the first migration to be applied, got %v (recorded %v)", applied, state.names())
+	}
+
+	state.failOn = ""
+	applied, err = Migrate(context.Background(), db, fsys)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if strings.Join(applied, ",") != "002_second.sql" || strings.Join(state.execs, " ") != "SELECT 1; SELECT 2;" {
+		t.Fatalf("expected only the failed migration to be retried, applied %v, statements %v", applied, state.execs)
+	}
+}
+
+func TestMigrate_RecordsBaselineOfUntrackedDatabase(t *testing.T) {
+	fsys := fstest.MapFS{
+		baselineMigration:     {Data: []byte("CREATE TABLE tokens ();")},
+		"002_hash_tokens.sql": {Data: []byte("SELECT 2;")},
+	}
+	state := &migrateDB{tokensTable: true}
+	db := openMigrateDB(t, state)
+
+	applied, err := Migrate(context.Background(), db, fsys)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if strings.Join(applied, ",") != "002_hash_tokens.sql" || strings.Join(state.execs, " ") != "SELECT 2;" {
+		t.Fatalf("expected the baseline to be skipped, applied %v, statements %v", applied, state.execs)
+	}
+	if strings.Join(state.names(), ",") != baselineMigration+",002_hash_tokens.sql" {
+		t.Fatalf("expected both migrations to be recorded, got %v", state.names())
+	}
+}
@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"auth-service/internal/domain"
+	"auth-service/internal/tokens"
+)
+
+// tokenIDPattern matches a token ID: the token digest or a leading part of at least 8 characters.
+var tokenIDPattern = regexp.MustCompile(`^[0-9a-f]{8,64}$`)
+
+const recordColumns = `token_hash, token_prefix, rate_limit, scope, COALESCE(comment, ''), created_at, not_before, expires_at, revoked_at`
+
+// ListTokens returns all tokens, oldest first.
+func (r *TokenRepository) ListTokens(ctx context.Context) ([]tokens.Record, error) {
+	db, err := r.DB.Get(r.DSN)
+	if err != nil {
+		return nil, err
+	}
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(cctx, `SELECT `+recordColumns+` FROM tokens ORDER BY created_at, token_hash;`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []tokens.Record
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// ResolveToken returns the token whose ID is or starts with id. It returns domain.ErrTokenNotFound
+// if there is none and domain.ErrAmbiguousTokenID if there are several.
+func (r *TokenRepository) ResolveToken(ctx context.Context, id string) (tokens.Record, error) {
+	id = strings.ToLower(id)
+	if !tokenIDPattern.MatchString(id) {
+		return tokens.Record{}, fmt.Errorf("%w: %q", domain.ErrTokenNotFound, id)
+	}
+	db, err := r.DB.Get(r.DSN)
+	if err != nil {
+		return tokens.Record{}, err
+	}
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(cctx, `SELECT `+recordColumns+` FROM tokens WHERE starts_with(token_hash, $1) LIMIT 2;`, id)
+	if err != nil {
+		return tokens.Record{}, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var found []tokens.Record
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return tokens.Record{}, err
+		}
+		found = append(found, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return tokens.Record{}, err
+	}
+	switch len(found) {
+	case 0:
+		return tokens.Record{}, fmt.Errorf("%w: %q", domain.ErrTokenNotFound, id)
+	case 1:
+		return found[0], nil
+	}
+	return tokens.Record{}, fmt.Errorf("%w: %q", domain.ErrAmbiguousTokenID, id)
+}
+
+// CreateToken stores a new token and returns it with its creation time.
+func (r *TokenRepository) CreateToken(ctx context.Context, rec tokens.Record) (tokens.Record, error) {
+	db, err := r.DB.Get(r.DSN)
+	if err != nil {
+		return tokens.Record{}, err
+	}
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return insertRecord(cctx, db, rec)
+}
+
+// UpdateToken writes the rate limit, scope, comment and validity window of an existing token.
+func (r *TokenRepository) UpdateToken(ctx context.Context, rec tokens.Record) error {
+	db, err := r.DB.Get(r.DSN)
+	if err != nil {
+		return err
+	}
+	scope, err := json.Marshal(rec.Scope)
+	if err != nil {
+		return err
+	}
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := db.ExecContext(cctx,
+		`UPDATE tokens SET rate_limit = $2, scope = $3, comment = NULLIF($4, ''), not_before = $5, expires_at = $6 WHERE token_hash = $1;`,
+		rec.ID, rec.RateLimit, string(scope), rec.Comment, nullTime(rec.NotBefore), nullTime(rec.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	return expectRow(res, rec.ID)
+}
+
+// RevokeToken revokes a token at the given time. An earlier revocation is kept.
+func (r *TokenRepository) RevokeToken(ctx context.Context, id string, at time.Time) error {
+	db, err := r.DB.Get(r.DSN)
+	if err != nil {
+		return err
+	}
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return revoke(cctx, db, id, at)
+}
+
+// RotateToken stores next, a copy of token id with a new secret, and revokes id at revokeAt, in
+// one transaction.
+func (r *TokenRepository) RotateToken(ctx context.Context, id string, next tokens.Record, revokeAt time.Time) (tokens.Record, error) {
+	db, err := r.DB.Get(r.DSN)
+	if err != nil {
+		return tokens.Record{}, err
+	}
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(cctx, nil)
+	if err != nil {
+		return tokens.Record{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	created, err := insertRecord(cctx, tx, next)
+	if err != nil {
+		return tokens.Record{}, err
+	}
+	if err := revoke(cctx, tx, id, revokeAt); err != nil {
+		return tokens.Record{}, err
+	}
+	return created, tx.Commit()
+}
+
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertRecord(ctx context.Context, db execQuerier, rec tokens.Record) (tokens.Record, error) {
+	scope, err := json.Marshal(rec.Scope)
+	if err != nil {
+		return tokens.Record{}, err
+	}
+	err = db.QueryRowContext(ctx,
+		`INSERT INTO tokens (token_hash, token_prefix, rate_limit, scope, comment, not_before, expires_at)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		 RETURNING created_at;`,
+		rec.ID, rec.Prefix, rec.RateLimit, string(scope), rec.Comment, nullTime(rec.NotBefore), nullTime(rec.ExpiresAt),
+	).Scan(&rec.CreatedAt)
+	if err != nil {
+		return tokens.Record{}, err
+	}
+	return rec, nil
+}
+
+func revoke(ctx context.Context, db execQuerier, id string, at time.Time) error {
+	res, err := db.ExecContext(ctx,
+		`UPDATE tokens SET revoked_at = LEAST(COALESCE(revoked_at, $2), $2) WHERE token_hash = $1;`, id, at)
+	if err != nil {
+		return err
+	}
+	return expectRow(res, id)
+}
+
+func expectRow(res sql.Result, id string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %q", domain.ErrTokenNotFound, id)
+	}
+	return nil
+}
+
+func scanRecord(rows *sql.Rows) (tokens.Record, error) {
+	var rec tokens.Record
+	var scopeRaw []byte
+	var notBefore, expiresAt, revokedAt sql.NullTime
+	if err := rows.Scan(&rec.ID, &rec.Prefix, &rec.RateLimit, &scopeRaw, &rec.Comment, &rec.CreatedAt, &notBefore, &expiresAt, &revokedAt); err != nil {
+		return tokens.Record{}, err
+	}
+	rec.Scope = tokens.Scope{}
+	if len(scopeRaw) > 0 {
+		if err := json.Unmarshal(scopeRaw, &rec.Scope); err != nil {
+			return tokens.Record{}, err
+		}
+	}
+	rec.NotBefore, rec.ExpiresAt, rec.RevokedAt = notBefore.Time, expiresAt.Time, revokedAt.Time
+	return rec, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
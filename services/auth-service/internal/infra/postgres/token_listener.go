@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"auth-service/internal/tokens"
+)
+
+// TokenChangeChannel is the channel trg_tokens_notify (004_notify_token_changes.sql) notifies with
+// the token_hash of each changed row, or "*" after the table was truncated.
+const TokenChangeChannel = "auth_tokens_changed"
+
+// DefaultListenHeartbeat is how long TokenListener waits for a notification before it pings the
+// connection.
+const DefaultListenHeartbeat = 30 * time.Second
+
+// TokenListener is a tokens.ChangeFeed backed by LISTEN on TokenChangeChannel. It holds its own
+// connection, as a listening session cannot be shared through the database/sql pool.
+type TokenListener struct {
+	DSN string
+
+	// Heartbeat bounds each wait for a notification; the connection is then pinged, also within
+	// Heartbeat, so a half-open connection (idle timeout, partition) fails instead of hanging.
+	Heartbeat time.Duration
+}
+
+func NewTokenListener(dsn string) *TokenListener {
+	return &TokenListener{DSN: dsn, Heartbeat: DefaultListenHeartbeat}
+}
+
+// Watch listens for token changes and calls apply with the current state of each changed row. It
+// reports a reset once listening, and whenever the table was truncated.
+func (l *TokenListener) Watch(ctx context.Context, apply func(tokens.Change)) error {
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	conn, err := pgx.Connect(cctx, l.DSN)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+TokenChangeChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	apply(tokens.Change{Reset: true})
+
+	for {
+		wctx, cancel := context.WithTimeout(ctx, l.Heartbeat)
+		n, err := conn.WaitForNotification(wctx)
+		cancel()
+		if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			pctx, cancel := context.WithTimeout(ctx, l.Heartbeat)
+			err = conn.Ping(pctx)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				return fmt.Errorf("heartbeat: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if n.Payload == "*" {
+			apply(tokens.Change{Reset: true})
+			continue
+		}
+		ch, err := fetchChange(ctx, conn, n.Payload)
+		if err != nil {
+			return fmt.Errorf("fetch token change: %w", err)
+		}
+		apply(ch)
+	}
+}
+
+// fetchChange reads the current state of the token with the given digest; a missing row means
+// it was deleted.
+func fetchChange(ctx context.Context, conn *pgx.Conn, digest string) (tokens.Change, error) {
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	row := conn.QueryRow(cctx, "SELECT "+entryColumns+" FROM fn_fetch_auth_tokens() WHERE token_hash = $1;", digest)
+	got, entry, err := scanEntry(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return tokens.Change{Digest: digest, Deleted: true}, nil
+	}
+	if err != nil {
+		return tokens.Change{}, err
+	}
+	return tokens.Change{Digest: got, Entry: entry}, nil
+}
+
+// compile-time check
+var _ tokens.ChangeFeed = (*TokenListener)(nil)
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+
+	"auth-service/internal/tokens"
+)
+
+// fakePostgres speaks just enough of the wire protocol for TokenListener: startup, LISTEN and
+// pings. Once stalled, it keeps reading but never answers, like a half-open connection.
+type fakePostgres struct {
+	stall atomic.Bool
+	pings atomic.Int32
+}
+
+func startFakePostgres(t *testing.T) (*fakePostgres, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	f := &fakePostgres{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, fmt.Sprintf("postgres://auth@%s/auth?sslmode=disable", ln.Addr())
+}
+
+func (f *fakePostgres) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	be := pgproto3.NewBackend(conn, conn)
+	if msg, err := be.ReceiveStartupMessage(); err != nil {
+		return
+	} else if _, ok := msg.(*pgproto3.StartupMessage); !ok {
+		return
+	}
+	be.Send(&pgproto3.AuthenticationOk{})
+	be.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: []byte{0, 0, 0, 1}})
+	be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if err := be.Flush(); err != nil {
+		return
+	}
+	for {
+		msg, err := be.Receive()
+		if err != nil {
+			return
+		}
+		q, ok := msg.(*pgproto3.Query)
+		if !ok || f.stall.Load() {
+			continue
+		}
+		if strings.HasPrefix(q.String, "LISTEN ") {
+			be.Send(&pgproto3.CommandComplete{CommandTag: []byte("LISTEN")})
+		} else {
+			f.pings.Add(1)
+			be.Send(&pgproto3.EmptyQueryResponse{})
+		}
+		be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		if err := be.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func TestTokenListener_HeartbeatKeepsIdleConnection(t *testing.T) {
+	server, dsn := startFakePostgres(t)
+	l := &TokenListener{DSN: dsn, Heartbeat: 20 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	var resets int
+	err := l.Watch(ctx, func(ch tokens.Change) {
+		if ch.Reset {
+			resets++
+		}
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Watch to run until ctx is done, got %v", err)
+	}
+	if resets != 1 {
+		t.Fatalf("expected one reset once listening, got %d", resets)
+	}
+	if server.pings.Load() < 2 {
+		t.Fatalf("expected the idle connection to be pinged, got %d pings", server.pings.Load())
+	}
+}
+
+func TestTokenListener_StalledConnectionFails(t *testing.T) {
+	server, dsn := startFakePostgres(t)
+	l := &TokenListener{DSN: dsn, Heartbeat: 20 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	err := l.Watch(ctx, func(ch tokens.Change) {
+		if ch.Reset {
+			server.stall.Store(true)
+		}
+	})
+	if err == nil || ctx.Err() != nil || !strings.Contains(err.Error(), "heartbeat") {
+		t.Fatalf("expected a heartbeat failure, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the stalled connection to be noticed within a few heartbeats, took %v", elapsed)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"auth-service/internal/tokens"
@@ -18,6 +19,7 @@ func NewTokenRepository(db *DB, dsn string) *TokenRepository {
 	return &TokenRepository{DB: db, DSN: dsn}
 }
 
+// LoadTokens returns all tokens keyed by their SHA-256 digest; plaintext tokens are never stored.
 func (r *TokenRepository) LoadTokens(ctx context.Context) (map[string]tokens.Entry, error) {
 	db, err := r.DB.Get(r.DSN)
 	if err != nil {
@@ -30,7 +32,7 @@ func (r *TokenRepository) LoadTokens(ctx context.Context) (map[string]tokens.Ent
 	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	rows, err := db.QueryContext(cctx, `SELECT token, rate_limit, scope FROM fn_fetch_auth_tokens();`)
+	rows, err := db.QueryContext(cctx, "SELECT "+entryColumns+" FROM fn_fetch_auth_tokens();")
 	if err != nil {
 		return nil, err
 	}
@@ -38,22 +40,11 @@ func (r *TokenRepository) LoadTokens(ctx context.Context) (map[string]tokens.Ent
 
 	out := make(map[string]tokens.Entry)
 	for rows.Next() {
-		var token string
-		var limit int
-		var scopeRaw []byte
-		if err := rows.Scan(&token, &limit, &scopeRaw); err != nil {
+		digest, entry, err := scanEntry(rows)
+		if err != nil {
 			return nil, err
 		}
-		scope := tokens.Scope{}
-		if len(scopeRaw) > 0 {
-			if err := json.Unmarshal(scopeRaw, &scope); err != nil {
-				return nil, err
-			}
-		}
-		out[token] = tokens.Entry{
-			RateLimit: limit,
-			Scope:     scope,
-		}
+		out[digest] = entry
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -61,9 +52,35 @@ func (r *TokenRepository) LoadTokens(ctx context.Context) (map[string]tokens.Ent
 	return out, nil
 }
 
+// entryColumns are the fn_fetch_auth_tokens columns read by scanEntry.
+const entryColumns = `token_hash, token_prefix, rate_limit, scope, not_before, expires_at, revoked_at`
+
+// scanEntry reads a row of entryColumns from a database/sql or pgx row.
+func scanEntry(row interface{ Scan(dest ...any) error }) (string, tokens.Entry, error) {
+	var digest, prefix string
+	var limit int
+	var scopeRaw []byte
+	var notBefore, expiresAt, revokedAt sql.NullTime
+	if err := row.Scan(&digest, &prefix, &limit, &scopeRaw, &notBefore, &expiresAt, &revokedAt); err != nil {
+		return "", tokens.Entry{}, err
+	}
+	scope := tokens.Scope{}
+	if len(scopeRaw) > 0 {
+		if err := json.Unmarshal(scopeRaw, &scope); err != nil {
+			return "", tokens.Entry{}, err
+		}
+	}
+	return strings.ToLower(digest), tokens.Entry{
+		Prefix:    prefix,
+		RateLimit: limit,
+		Scope:     scope,
+		NotBefore: notBefore.Time,
+		ExpiresAt: expiresAt.Time,
+		RevokedAt: revokedAt.Time,
+	}, nil
+}
+
 // compile-time check
 var _ interface {
 	LoadTokens(ctx context.Context) (map[string]tokens.Entry, error)
 } = (*TokenRepository)(nil)
-
-var _ *sql.DB
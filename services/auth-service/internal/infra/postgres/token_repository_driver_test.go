@@ -7,8 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
+
+	"auth-service/internal/tokens"
 )
 
 type drvMode struct {
@@ -20,6 +24,9 @@ type drvMode struct {
 var (
 	testDriverCounter atomic.Int64
 	testMode          drvMode
+
+	testNotBefore = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	testRevokedAt = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
 )
 
 type fakeDriver struct{}
@@ -55,8 +62,11 @@ func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.
 		row1Scope = []byte(`{bad`)
 	}
 	return &fakeRows{
-		cols: []string{"token", "rate_limit", "scope"},
-		data: [][]driver.Value{{"tok1", int64(5), row1Scope}, {"tok2", int64(2), []byte(`{"ops":true}`)}},
+		cols: []string{"token_hash", "token_prefix", "rate_limit", "scope", "not_before", "expires_at", "revoked_at"},
+		data: [][]driver.Value{
+			{strings.ToUpper(tokens.Digest("tok1-secret")), "tok1", int64(5), row1Scope, nil, nil, nil},
+			{tokens.Digest("tok2-secret"), "tok2", int64(2), []byte(`{"ops":true}`), testNotBefore, nil, testRevokedAt},
+		},
 	}, nil
 }
 
@@ -92,9 +102,20 @@ func TestTokenRepository_LoadTokens_DriverSuccess(t *testing.T) {
 	if err != nil {
 		t.Fatalf("load tokens: %v", err)
 	}
-	if out["tok1"].RateLimit != 5 || !out["tok1"].Scope["api"] {
+	tok1 := out[tokens.Digest("tok1-secret")]
+	if tok1.RateLimit != 5 || !tok1.Scope["api"] || tok1.Prefix != "tok1" {
 		t.Fatalf("unexpected output: %+v", out)
 	}
+	if !tok1.NotBefore.IsZero() || !tok1.ExpiresAt.IsZero() || !tok1.RevokedAt.IsZero() {
+		t.Fatalf("expected NULL timestamps to stay unset, got %+v", tok1)
+	}
+	tok2 := out[tokens.Digest("tok2-secret")]
+	if !tok2.NotBefore.Equal(testNotBefore) || !tok2.RevokedAt.Equal(testRevokedAt) || !tok2.ExpiresAt.IsZero() {
+		t.Fatalf("unexpected validity window: %+v", tok2)
+	}
+	if _, ok := out["tok1-secret"]; ok {
+		t.Fatalf("expected tokens to be keyed by digest, got %+v", out)
+	}
 }
 
 func TestTokenRepository_LoadTokens_SchemaError(t *testing.T) {
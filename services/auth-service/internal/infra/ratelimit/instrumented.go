@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth-service/internal/infra/metrics"
+)
+
+// instrumentedStorage counts backend errors per operation.
+type instrumentedStorage struct {
+	fiber.Storage
+}
+
+func (s instrumentedStorage) Get(key string) ([]byte, error) {
+	v, err := s.Storage.Get(key)
+	return v, countErr("get", err)
+}
+
+func (s instrumentedStorage) Set(key string, val []byte, exp time.Duration) error {
+	return countErr("set", s.Storage.Set(key, val, exp))
+}
+
+func (s instrumentedStorage) Delete(key string) error {
+	return countErr("delete", s.Storage.Delete(key))
+}
+
+func (s instrumentedStorage) Reset() error {
+	return countErr("reset", s.Storage.Reset())
+}
+
+func countErr(op string, err error) error {
+	if err != nil {
+		metrics.StorageErrors.WithLabelValues(op).Inc()
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"auth-service/internal/infra/metrics"
+)
+
+type failingStorage struct{ err error }
+
+func (s failingStorage) Get(string) ([]byte, error)              { return nil, s.err }
+func (s failingStorage) Set(string, []byte, time.Duration) error { return s.err }
+func (s failingStorage) Delete(string) error                     { return s.err }
+func (s failingStorage) Reset() error                            { return s.err }
+func (s failingStorage) Close() error                            { return nil }
+
+func TestInstrumentedStorage_CountsErrorsPerOperation(t *testing.T) {
+	getBefore := testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("get"))
+	setBefore := testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("set"))
+
+	s := instrumentedStorage{Storage: failingStorage{err: errors.New("redis down")}}
+	if _, err := s.Get("k"); err == nil {
+		t.Fatalf("expected Get error to be passed through")
+	}
+	if err := s.Set("k", []byte("v"), time.Second); err == nil {
+		t.Fatalf("expected Set error to be passed through")
+	}
+
+	if got := testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("get")) - getBefore; got != 1 {
+		t.Fatalf("expected 1 get error, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("set")) - setBefore; got != 1 {
+		t.Fatalf("expected 1 set error, got %v", got)
+	}
+
+	deleteBefore := testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("delete"))
+	ok := instrumentedStorage{Storage: failingStorage{}}
+	if err := ok.Delete("k"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.StorageErrors.WithLabelValues("delete")) - deleteBefore; got != 0 {
+		t.Fatalf("expected no delete errors counted, got %v", got)
+	}
+}
@@ -17,7 +17,7 @@ type RedisConfig struct {
 }
 
 // NewStore creates a Fiber storage backend used by the Fiber limiter middleware.
-// It falls back to in-memory storage if Redis init fails.
+// It falls back to in-memory storage if Redis init fails. Redis errors are counted in metrics.
 func NewStore(cfg RedisConfig) fiber.Storage {
 	var store fiber.Storage = memoryStorage.New() // safe default
 
@@ -32,11 +32,11 @@ func NewStore(cfg RedisConfig) fiber.Storage {
 				logging.Error("Redis limiter store init panicked, falling back to memory", "error", r)
 			}
 		}()
-		store = redisStorage.New(redisStorage.Config{
+		store = instrumentedStorage{redisStorage.New(redisStorage.Config{
 			Addrs:    []string{cfg.Addr},
 			Password: cfg.Password,
 			Database: cfg.DB,
-		})
+		})}
 		logging.Info("Using redis for rate limiting", "addr", cfg.Addr, "db", cfg.DB)
 	}()
 
@@ -0,0 +1,117 @@
+// Package tracing sets up OpenTelemetry tracing: W3C trace context propagation, an OTLP/HTTP or
+// stdout exporter and helpers to start spans from request contexts.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"auth-service/internal/config"
+)
+
+// Exporters (tracing.exporter).
+const (
+	ExporterOTLP   = "otlp"   // OTLP over HTTP to tracing.endpoint (default)
+	ExporterStdout = "stdout" // pretty-printed spans on stdout, for local debugging
+)
+
+const (
+	instrumentationName = "auth-service"
+	defaultServiceName  = "auth-service"
+)
+
+// Setup installs the W3C trace context propagator and, when tracing is enabled, a batching
+// tracer provider. The returned function flushes pending spans and must be called on shutdown.
+// With tracing disabled, spans are no-ops but incoming trace context is still propagated.
+func Setup(ctx context.Context, tc config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !tc.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, tc.Exporter, tc.Endpoint, tc.Insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := tc.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+
+	ratio := tc.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, kind, endpoint string, insecure bool) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("invalid tracing.exporter %q (expected %q or %q)", kind, ExporterOTLP, ExporterStdout)
+	}
+}
+
+// Tracer returns the auth-service tracer from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a child span of the span carried by ctx (if any).
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err (if any) on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the trace ID carried by ctx, or "" when ctx has no valid span context.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"auth-service/internal/config"
+)
+
+const parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func TestSetup_DisabledIsNoop(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+}
+
+func TestSetup_RejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), config.TracingConfig{Enabled: true, Exporter: "zipkin"}); err == nil {
+		t.Fatalf("expected error for unknown exporter")
+	}
+}
+
+func TestMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	rec := recordSpans(t)
+
+	app := fiber.New()
+	app.Use(Middleware())
+	var childTraceID string
+	app.Get("/ext-authz/*", func(c *fiber.Ctx) error {
+		ctx, span := Start(c.UserContext(), "child")
+		childTraceID = TraceID(ctx)
+		End(span, errors.New("boom"))
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/ext-authz/v0/pdf", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-00f067aa0ba902b7-01")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if childTraceID != parentTraceID {
+		t.Fatalf("expected child span in trace %s, got %q", parentTraceID, childTraceID)
+	}
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if child.Status().Code != codes.Error {
+		t.Fatalf("expected child span to record the error, got %v", child.Status())
+	}
+	if server.Name() != "GET /ext-authz/*" {
+		t.Fatalf("expected server span named after the route, got %q", server.Name())
+	}
+	if got := server.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Fatalf("expected server span parent from traceparent, got %s", got)
+	}
+}
+
+func TestMiddleware_MarksServerErrors(t *testing.T) {
+	rec := recordSpans(t)
+
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "busy")
+	})
+	if _, err := app.Test(httptest.NewRequest("GET", "/fail", nil)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 || spans[0].Status().Code != codes.Error {
+		t.Fatalf("expected one errored server span, got %+v", spans)
+	}
+}
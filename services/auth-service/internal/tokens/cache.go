@@ -1,22 +1,50 @@
 package tokens
 
-import "sync"
+import (
+	"crypto/subtle"
+	"sync"
+	"time"
+
+	"auth-service/internal/domain"
+)
 
 type Scope map[string]bool
 
 type Entry struct {
+	Prefix    string // public token prefix, see Prefix
 	RateLimit int
 	Scope     Scope
+
+	// Validity window; zero values are unset.
+	NotBefore time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time
 }
 
-// Cache keeps token -> entry in memory for fast lookup.
+// Check returns why the entry is not usable at now, or nil.
+func (e Entry) Check(now time.Time) error {
+	switch {
+	case !e.RevokedAt.IsZero() && !now.Before(e.RevokedAt):
+		return domain.ErrAPIKeyRevoked
+	case !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt):
+		return domain.ErrAPIKeyExpired
+	case !e.NotBefore.IsZero() && now.Before(e.NotBefore):
+		return domain.ErrAPIKeyNotYetValid
+	}
+	return nil
+}
+
+// Cache keeps token digest -> entry in memory for fast lookup. Presented tokens are hashed and
+// matched against the digests sharing their prefix in constant time.
 type Cache struct {
-	mu sync.RWMutex
-	m  map[string]Entry
+	mu       sync.RWMutex
+	m        map[string]Entry
+	byPrefix map[string][]string
+	now      func() time.Time
 }
 
 func NewCache() *Cache {
-	return &Cache{}
+	return &Cache{now: time.Now}
 }
 
 func (c *Cache) Ready() bool {
@@ -26,39 +54,112 @@ func (c *Cache) Ready() bool {
 }
 
 func (c *Cache) Validate(token string) bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.m == nil {
-		return false
+	return c.Check(token) == nil
+}
+
+// Check returns nil if token is known and within its validity window at the current time,
+// domain.ErrInvalidAPIKey if it is unknown, and ErrAPIKeyRevoked, ErrAPIKeyExpired or
+// ErrAPIKeyNotYetValid otherwise.
+func (c *Cache) Check(token string) error {
+	entry, ok := c.lookup(token)
+	if !ok {
+		return domain.ErrInvalidAPIKey
 	}
-	_, ok := c.m[token]
-	return ok
+	return entry.Check(c.now())
 }
 
 func (c *Cache) RateLimit(token string) int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.m == nil {
-		return 0
-	}
-	return c.m[token].RateLimit
+	entry, _ := c.lookup(token)
+	return entry.RateLimit
 }
 
 func (c *Cache) HasScope(token, scope string) bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.m == nil {
-		return false
-	}
-	entry, ok := c.m[token]
-	if !ok {
-		return false
-	}
+	entry, _ := c.lookup(token)
 	return entry.Scope[scope]
 }
 
+// Scopes returns the scopes granted to token, sorted.
+func (c *Cache) Scopes(token string) []string {
+	entry, _ := c.lookup(token)
+	return entry.Scope.Granted()
+}
+
+// Replace swaps in a new set of entries keyed by token digest (see Digest). The cache takes
+// ownership of all; later Set and Delete calls modify it.
 func (c *Cache) Replace(all map[string]Entry) {
+	byPrefix := make(map[string][]string, len(all))
+	for digest, entry := range all {
+		byPrefix[entry.Prefix] = append(byPrefix[entry.Prefix], digest)
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.m = all
+	c.byPrefix = byPrefix
+}
+
+// Set adds or replaces the entry for digest. It is a no-op until the first Replace, so a partial
+// set of entries never makes the cache look ready.
+func (c *Cache) Set(digest string, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == nil {
+		return
+	}
+	c.remove(digest)
+	c.m[digest] = entry
+	c.byPrefix[entry.Prefix] = append(c.byPrefix[entry.Prefix], digest)
+}
+
+// Delete removes the entry for digest, if any.
+func (c *Cache) Delete(digest string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(digest)
+}
+
+// Len returns the number of entries.
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.m)
+}
+
+// remove drops digest from both maps; c.mu must be held for writing.
+func (c *Cache) remove(digest string) {
+	old, ok := c.m[digest]
+	if !ok {
+		return
+	}
+	delete(c.m, digest)
+	digests := c.byPrefix[old.Prefix]
+	for i, d := range digests {
+		if d == digest {
+			digests = append(digests[:i:i], digests[i+1:]...)
+			break
+		}
+	}
+	if len(digests) == 0 {
+		delete(c.byPrefix, old.Prefix)
+	} else {
+		c.byPrefix[old.Prefix] = digests
+	}
+}
+
+// lookup finds the entry of a presented token. Every digest sharing the token's prefix is
+// compared, without stopping at the first match, so timing reveals nothing beyond the prefix.
+func (c *Cache) lookup(token string) (Entry, bool) {
+	digest := []byte(Digest(token))
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var match string
+	found := false
+	for _, candidate := range c.byPrefix[Prefix(token)] {
+		if subtle.ConstantTimeCompare([]byte(candidate), digest) == 1 {
+			match, found = candidate, true
+		}
+	}
+	if !found {
+		return Entry{}, false
+	}
+	return c.m[match], true
 }
@@ -1,6 +1,12 @@
 package tokens
 
-import "testing"
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"auth-service/internal/domain"
+)
 
 func TestCache_ReadyValidateRateLimit(t *testing.T) {
 	c := NewCache()
@@ -14,12 +20,12 @@ func TestCache_ReadyValidateRateLimit(t *testing.T) {
 		t.Fatalf("expected rate limit 0 when not ready, got %d", got)
 	}
 
-	c.Replace(map[string]Entry{
+	c.Replace(hashed(map[string]Entry{
 		"abc": {
 			RateLimit: 10,
 			Scope:     Scope{"api": true, "ops": false},
 		},
-	})
+	}))
 	if !c.Ready() {
 		t.Fatalf("expected cache ready after Replace")
 	}
@@ -41,4 +47,125 @@ func TestCache_ReadyValidateRateLimit(t *testing.T) {
 	if !c.HasScope("abc", "api") {
 		t.Fatalf("expected api scope to be true")
 	}
+	if got := c.Scopes("abc"); len(got) != 1 || got[0] != "api" {
+		t.Fatalf("expected granted scopes [api], got %v", got)
+	}
+	if got := c.Scopes("missing"); got != nil {
+		t.Fatalf("expected no scopes for missing token, got %v", got)
+	}
+}
+
+func TestCache_MatchesDigestNotPlaintext(t *testing.T) {
+	c := NewCache()
+	c.Replace(hashed(map[string]Entry{
+		"sk_live_first-secret":  {RateLimit: 1},
+		"sk_live_second-secret": {RateLimit: 2},
+	}))
+
+	// Both tokens share the prefix "sk_live_", so the digests must tell them apart.
+	if got := c.RateLimit("sk_live_first-secret"); got != 1 {
+		t.Fatalf("expected rate limit 1, got %d", got)
+	}
+	if got := c.RateLimit("sk_live_second-secret"); got != 2 {
+		t.Fatalf("expected rate limit 2, got %d", got)
+	}
+	if c.Validate("sk_live_third-secret") {
+		t.Fatalf("expected an unknown token with a known prefix to be rejected")
+	}
+	if c.Validate(Digest("sk_live_first-secret")) {
+		t.Fatalf("expected the stored digest not to be accepted as a token")
+	}
+}
+
+func TestDigestAndPrefix(t *testing.T) {
+	// sha256("abc")
+	if got := Digest("abc"); got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Fatalf("unexpected digest %s", got)
+	}
+	tests := map[string]string{
+		"abc123-test-first-token": "abc123-t",
+		"abcdef":                  "abc",
+		"a":                       "",
+		"äöüäöüäöüäöüäöüäöü":      "äöüäöüäö",
+	}
+	for token, want := range tests {
+		if got := Prefix(token); got != want {
+			t.Errorf("Prefix(%q) = %q, want %q", token, got, want)
+		}
+	}
+}
+
+func TestCache_CheckValidityWindow(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCache()
+	c.now = func() time.Time { return now }
+	c.Replace(hashed(map[string]Entry{
+		"plain-token-000":  {},
+		"expired-token-01": {ExpiresAt: now.Add(-time.Second)},
+		"expiring-token-1": {ExpiresAt: now.Add(time.Hour)},
+		"revoked-token-01": {RevokedAt: now, ExpiresAt: now.Add(-time.Hour)},
+		"scheduled-revoke": {RevokedAt: now.Add(time.Hour)},
+		"future-token-001": {NotBefore: now.Add(time.Minute)},
+		"started-token-01": {NotBefore: now},
+	}))
+
+	tests := map[string]error{
+		"plain-token-000":  nil,
+		"expired-token-01": domain.ErrAPIKeyExpired,
+		"expiring-token-1": nil,
+		"revoked-token-01": domain.ErrAPIKeyRevoked,
+		"scheduled-revoke": nil,
+		"future-token-001": domain.ErrAPIKeyNotYetValid,
+		"started-token-01": nil,
+		"unknown-token-01": domain.ErrInvalidAPIKey,
+	}
+	for token, want := range tests {
+		if err := c.Check(token); !errors.Is(err, want) || (want == nil && err != nil) {
+			t.Errorf("Check(%s) = %v, want %v", token, err, want)
+		}
+		if got := c.Validate(token); got != (want == nil) {
+			t.Errorf("Validate(%s) = %v", token, got)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := c.Check("scheduled-revoke"); !errors.Is(err, domain.ErrAPIKeyRevoked) {
+		t.Fatalf("expected a scheduled revocation to take effect without a reload, got %v", err)
+	}
+}
+
+func TestCache_SetDelete(t *testing.T) {
+	c := NewCache()
+	c.Set(Digest("early-token-0001"), Entry{Prefix: Prefix("early-token-0001"), RateLimit: 1})
+	if c.Ready() || c.Validate("early-token-0001") {
+		t.Fatalf("expected Set before the first Replace to be ignored")
+	}
+
+	// Both tokens share the prefix "shared-p".
+	c.Replace(hashed(map[string]Entry{"shared-prefix-one": {RateLimit: 1}}))
+	c.Set(Digest("shared-prefix-two"), Entry{Prefix: Prefix("shared-prefix-two"), RateLimit: 2})
+	c.Set(Digest("shared-prefix-one"), Entry{Prefix: Prefix("shared-prefix-one"), RateLimit: 3})
+	if c.Len() != 2 || c.RateLimit("shared-prefix-one") != 3 || c.RateLimit("shared-prefix-two") != 2 {
+		t.Fatalf("unexpected cache after Set: len %d, limits %d/%d", c.Len(), c.RateLimit("shared-prefix-one"), c.RateLimit("shared-prefix-two"))
+	}
+
+	c.Delete(Digest("shared-prefix-one"))
+	c.Delete(Digest("never-stored-token"))
+	if c.Validate("shared-prefix-one") || !c.Validate("shared-prefix-two") || c.Len() != 1 {
+		t.Fatalf("expected only the deleted token to be gone")
+	}
+	c.Delete(Digest("shared-prefix-two"))
+	if !c.Ready() || c.Len() != 0 {
+		t.Fatalf("expected an empty but ready cache")
+	}
+}
+
+// hashed keys entries by token digest, the way the repository loads them.
+func hashed(byToken map[string]Entry) map[string]Entry {
+	out := make(map[string]Entry, len(byToken))
+	for token, entry := range byToken {
+		entry.Prefix = Prefix(token)
+		out[Digest(token)] = entry
+	}
+	return out
 }
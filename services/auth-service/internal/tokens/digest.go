@@ -0,0 +1,35 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// PrefixLen is the length of the public token prefix stored next to the digest. Tokens shorter
+// than twice that keep only their first half public.
+const PrefixLen = 8
+
+// Digest returns the hex-encoded SHA-256 of token, the form tokens are stored in. API keys are
+// random and long, so an unsalted hash is enough to keep a database dump from leaking them.
+func Digest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Prefix returns the public part of token used to find its digest and to tell tokens apart in
+// logs and admin tools. It matches left(token, least(8, length(token) / 2)) in the migrations.
+func Prefix(token string) string {
+	r := []rune(token)
+	return string(r[:min(PrefixLen, len(r)/2)])
+}
+
+// Generate returns a new random API key (256 bits, URL-safe base64).
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,148 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"auth-service/internal/domain"
+)
+
+// DefaultRateLimit matches the tokens.rate_limit column default.
+const DefaultRateLimit = 60
+
+// scopePattern restricts scope names so they survive the comma-separated X-Auth-Scopes header.
+var scopePattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// Record is a stored token as managed through the admin API and authctl. ID is the token
+// digest; the token itself is only known when it is generated.
+type Record struct {
+	ID        string
+	Prefix    string
+	RateLimit int
+	Scope     Scope
+	Comment   string
+	CreatedAt time.Time
+	NotBefore time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+// NewScope grants the given scopes.
+func NewScope(scopes []string) Scope {
+	scope := make(Scope, len(scopes))
+	for _, s := range scopes {
+		scope[s] = true
+	}
+	return scope
+}
+
+// Granted returns the granted scopes, sorted.
+func (s Scope) Granted() []string {
+	var scopes []string
+	for scope, granted := range s {
+		if granted {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// Issue generates a new token for rec, returning the record with its ID and prefix set and the
+// token, which is not stored anywhere.
+func Issue(rec Record) (Record, string, error) {
+	secret, err := Generate()
+	if err != nil {
+		return Record{}, "", err
+	}
+	rec.ID, rec.Prefix = Digest(secret), Prefix(secret)
+	return rec, secret, nil
+}
+
+// Entry returns the part of the record the cache needs.
+func (r Record) Entry() Entry {
+	return Entry{
+		Prefix:    r.Prefix,
+		RateLimit: r.RateLimit,
+		Scope:     r.Scope,
+		NotBefore: r.NotBefore,
+		ExpiresAt: r.ExpiresAt,
+		RevokedAt: r.RevokedAt,
+	}
+}
+
+// Validate checks the settings an administrator can change.
+func (r Record) Validate() error {
+	if r.RateLimit < 0 {
+		return errors.New("rate_limit must be >= 0")
+	}
+	for _, scope := range r.Scope.Granted() {
+		if !scopePattern.MatchString(scope) {
+			return fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+	if !r.NotBefore.IsZero() && !r.ExpiresAt.IsZero() && !r.ExpiresAt.After(r.NotBefore) {
+		return errors.New("expires_at must be after not_before")
+	}
+	return nil
+}
+
+// Status describes the record at now: active, revoked, expired or not_yet_valid.
+func (r Record) Status(now time.Time) string {
+	switch err := r.Entry().Check(now); {
+	case errors.Is(err, domain.ErrAPIKeyRevoked):
+		return "revoked"
+	case errors.Is(err, domain.ErrAPIKeyExpired):
+		return "expired"
+	case errors.Is(err, domain.ErrAPIKeyNotYetValid):
+		return "not_yet_valid"
+	}
+	return "active"
+}
+
+// View is the JSON form of a record shown by the admin API and authctl. Token is only set when
+// a token was just generated; it cannot be retrieved later.
+type View struct {
+	ID        string     `json:"id"`
+	Prefix    string     `json:"prefix"`
+	Token     string     `json:"token,omitempty"`
+	Status    string     `json:"status"`
+	RateLimit int        `json:"rate_limit"`
+	Scopes    []string   `json:"scopes"`
+	Comment   string     `json:"comment,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// View returns the record as seen at now, with token if it was just generated.
+func (r Record) View(now time.Time, token string) View {
+	scopes := r.Scope.Granted()
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return View{
+		ID:        r.ID,
+		Prefix:    r.Prefix,
+		Token:     token,
+		Status:    r.Status(now),
+		RateLimit: r.RateLimit,
+		Scopes:    scopes,
+		Comment:   r.Comment,
+		CreatedAt: r.CreatedAt,
+		NotBefore: timePtr(r.NotBefore),
+		ExpiresAt: timePtr(r.ExpiresAt),
+		RevokedAt: timePtr(r.RevokedAt),
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
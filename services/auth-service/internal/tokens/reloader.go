@@ -2,31 +2,90 @@ package tokens
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"auth-service/internal/infra/logging"
+	"auth-service/internal/infra/metrics"
 )
 
 type Repository interface {
 	LoadTokens(ctx context.Context) (map[string]Entry, error)
 }
 
+// Change is a single token change reported by a ChangeFeed.
+type Change struct {
+	Digest  string
+	Entry   Entry
+	Deleted bool
+
+	// Reset means changes may have been missed (e.g. the feed reconnected) and calls for a full
+	// reload instead; Digest and Entry are unset.
+	Reset bool
+}
+
+// ChangeFeed streams token changes as they are committed (postgres.TokenListener).
+type ChangeFeed interface {
+	// Watch calls apply for each change, in order, until ctx is done or the feed fails. It
+	// reports a Reset once it is listening, as changes before that are not reported.
+	Watch(ctx context.Context, apply func(Change)) error
+}
+
+// Reloader keeps the cache in sync with the repository: Start reloads all tokens periodically and
+// Listen applies individual changes as they happen.
 type Reloader struct {
 	repo     Repository
 	cache    *Cache
 	interval time.Duration
+
+	// loadMu serializes full loads. mu guards pending, the changes applied since the running load
+	// started; they are replayed onto its result, which may predate them. pending is nil while
+	// no load is running.
+	loadMu  sync.Mutex
+	mu      sync.Mutex
+	pending []Change
+
+	// listenBackoff is the first delay before Listen watches again after the feed failed; it
+	// doubles up to maxListenBackoff.
+	listenBackoff time.Duration
 }
 
+const maxListenBackoff = 30 * time.Second
+
 func NewReloader(repo Repository, cache *Cache, interval time.Duration) *Reloader {
-	return &Reloader{repo: repo, cache: cache, interval: interval}
+	return &Reloader{repo: repo, cache: cache, interval: interval, listenBackoff: time.Second}
 }
 
 func (r *Reloader) LoadOnce(ctx context.Context) error {
+	r.loadMu.Lock()
+	defer r.loadMu.Unlock()
+
+	r.mu.Lock()
+	r.pending = []Change{}
+	r.mu.Unlock()
+
+	start := time.Now()
 	m, err := r.repo.LoadTokens(ctx)
+	metrics.TokenLoadDuration.Observe(time.Since(start).Seconds())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := r.pending
+	r.pending = nil
 	if err != nil {
+		metrics.TokenLoadErrors.Inc()
 		return err
 	}
+	for _, ch := range pending {
+		if ch.Deleted {
+			delete(m, ch.Digest)
+		} else {
+			m[ch.Digest] = ch.Entry
+		}
+	}
 	r.cache.Replace(m)
+	metrics.TokenCacheSize.Set(float64(len(m)))
+	metrics.TokenLastReload.SetToCurrentTime()
 	return nil
 }
 
@@ -46,3 +105,53 @@ func (r *Reloader) Start(ctx context.Context) {
 		}
 	}()
 }
+
+// Listen applies changes from feed to the cache until ctx is done, watching again with backoff
+// whenever the feed fails. The periodic reload (Start) remains the fallback for missed changes.
+func (r *Reloader) Listen(ctx context.Context, feed ChangeFeed) {
+	go func() {
+		backoff := r.listenBackoff
+		for {
+			started := time.Now()
+			err := feed.Watch(ctx, func(ch Change) { r.apply(ctx, ch) })
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(started) > maxListenBackoff {
+				backoff = r.listenBackoff
+			}
+			logging.Warn("Token change feed failed; retrying", "error", err, "retry_in", backoff.String())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, maxListenBackoff)
+		}
+	}()
+}
+
+// apply updates the cache with a single change; a Reset reloads all tokens.
+func (r *Reloader) apply(ctx context.Context, ch Change) {
+	if ch.Reset {
+		metrics.TokenChanges.WithLabelValues("reset").Inc()
+		if err := r.LoadOnce(ctx); err != nil {
+			logging.Error("Token reload after change feed reset failed", "error", err)
+		}
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending != nil {
+		r.pending = append(r.pending, ch)
+	}
+	if ch.Deleted {
+		metrics.TokenChanges.WithLabelValues("delete").Inc()
+		r.cache.Delete(ch.Digest)
+	} else {
+		metrics.TokenChanges.WithLabelValues("upsert").Inc()
+		r.cache.Set(ch.Digest, ch.Entry)
+	}
+	metrics.TokenCacheSize.Set(float64(r.cache.Len()))
+}
@@ -7,6 +7,10 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"auth-service/internal/infra/metrics"
 )
 
 type fakeRepo struct {
@@ -27,12 +31,12 @@ func (r fakeRepo) LoadTokens(ctx context.Context) (map[string]Entry, error) {
 
 func TestReloader_LoadOnce_Success(t *testing.T) {
 	c := NewCache()
-	r := NewReloader(fakeRepo{m: map[string]Entry{
+	r := NewReloader(fakeRepo{m: hashed(map[string]Entry{
 		"k": {
 			RateLimit: 3,
 			Scope:     Scope{"api": true},
 		},
-	}}, c, time.Hour)
+	})}, c, time.Hour)
 
 	if err := r.LoadOnce(context.Background()); err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -47,12 +51,12 @@ func TestReloader_LoadOnce_Success(t *testing.T) {
 
 func TestReloader_LoadOnce_Error_DoesNotReplace(t *testing.T) {
 	c := NewCache()
-	c.Replace(map[string]Entry{
+	c.Replace(hashed(map[string]Entry{
 		"keep": {
 			RateLimit: 7,
 			Scope:     Scope{"api": true},
 		},
-	})
+	}))
 
 	expectedErr := errors.New("boom")
 	r := NewReloader(fakeRepo{err: expectedErr}, c, time.Hour)
@@ -99,8 +103,8 @@ func TestReloader_Start_RefreshesTokens(t *testing.T) {
 		m   map[string]Entry
 		err error
 	}{
-		{m: map[string]Entry{"k": {RateLimit: 1, Scope: Scope{"api": true}}}},
-		{m: map[string]Entry{"k": {RateLimit: 5, Scope: Scope{"api": true}}}},
+		{m: hashed(map[string]Entry{"k": {RateLimit: 1, Scope: Scope{"api": true}}})},
+		{m: hashed(map[string]Entry{"k": {RateLimit: 5, Scope: Scope{"api": true}}})},
 	}}
 
 	r := NewReloader(repo, c, 20*time.Millisecond)
@@ -120,9 +124,9 @@ func TestReloader_Start_RefreshesTokens(t *testing.T) {
 
 func TestReloader_Start_DBDownKeepsExistingCache(t *testing.T) {
 	c := NewCache()
-	c.Replace(map[string]Entry{
+	c.Replace(hashed(map[string]Entry{
 		"keep": {RateLimit: 9, Scope: Scope{"api": true}},
-	})
+	}))
 	repo := &sequenceRepo{results: []struct {
 		m   map[string]Entry
 		err error
@@ -143,3 +147,145 @@ func TestReloader_Start_DBDownKeepsExistingCache(t *testing.T) {
 		t.Fatalf("expected repo to be called at least once")
 	}
 }
+
+func TestReloader_LoadOnce_UpdatesMetrics(t *testing.T) {
+	c := NewCache()
+	r := NewReloader(fakeRepo{m: hashed(map[string]Entry{
+		"a": {RateLimit: 1, Scope: Scope{"api": true}},
+		"b": {RateLimit: 1, Scope: Scope{"api": true}},
+	})}, c, time.Hour)
+
+	if err := r.LoadOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.TokenCacheSize); got != 2 {
+		t.Fatalf("expected token cache size 2, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.TokenLastReload); got <= 0 {
+		t.Fatalf("expected last reload timestamp to be set, got %v", got)
+	}
+
+	errsBefore := testutil.ToFloat64(metrics.TokenLoadErrors)
+	failing := NewReloader(fakeRepo{err: errors.New("boom")}, c, time.Hour)
+	_ = failing.LoadOnce(context.Background())
+	if got := testutil.ToFloat64(metrics.TokenLoadErrors) - errsBefore; got != 1 {
+		t.Fatalf("expected 1 load error, got %v", got)
+	}
+}
+
+// blockingRepo returns m from LoadTokens once release is closed.
+type blockingRepo struct {
+	m       map[string]Entry
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRepo) LoadTokens(ctx context.Context) (map[string]Entry, error) {
+	close(r.started)
+	<-r.release
+	return r.m, nil
+}
+
+func TestReloader_ChangesDuringLoadAreReplayed(t *testing.T) {
+	c := NewCache()
+	c.Replace(hashed(map[string]Entry{"old": {RateLimit: 1}}))
+	repo := &blockingRepo{
+		// The snapshot predates both changes below.
+		m:       hashed(map[string]Entry{"old": {RateLimit: 1}, "gone": {RateLimit: 1}}),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	r := NewReloader(repo, c, time.Hour)
+
+	done := make(chan error)
+	go func() { done <- r.LoadOnce(context.Background()) }()
+	<-repo.started
+	ctx := context.Background()
+	r.apply(ctx, Change{Digest: Digest("new"), Entry: Entry{Prefix: Prefix("new"), RateLimit: 4}})
+	r.apply(ctx, Change{Digest: Digest("gone"), Deleted: true})
+	if c.RateLimit("new") != 4 {
+		t.Fatalf("expected the change to apply while the load is running")
+	}
+	close(repo.release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.RateLimit("new") != 4 || c.Validate("gone") || !c.Validate("old") {
+		t.Fatalf("expected the changes to survive the reload")
+	}
+	if got := testutil.ToFloat64(metrics.TokenCacheSize); got != 2 {
+		t.Fatalf("expected token cache size 2, got %v", got)
+	}
+}
+
+// fakeFeed reports changes on each Watch and then fails with err, or blocks until ctx is done.
+type fakeFeed struct {
+	changes []Change
+	err     error
+	watches atomic.Int32
+}
+
+func (f *fakeFeed) Watch(ctx context.Context, apply func(Change)) error {
+	f.watches.Add(1)
+	for _, ch := range f.changes {
+		apply(ch)
+	}
+	if f.err != nil {
+		return f.err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestReloader_Listen_AppliesChanges(t *testing.T) {
+	c := NewCache()
+	repo := &sequenceRepo{results: []struct {
+		m   map[string]Entry
+		err error
+	}{
+		{m: hashed(map[string]Entry{"k": {RateLimit: 1}, "drop": {RateLimit: 1}})},
+	}}
+	feed := &fakeFeed{changes: []Change{
+		{Reset: true},
+		{Digest: Digest("k"), Entry: Entry{Prefix: Prefix("k"), RateLimit: 8}},
+		{Digest: Digest("drop"), Deleted: true},
+	}}
+	upserts := testutil.ToFloat64(metrics.TokenChanges.WithLabelValues("upsert"))
+
+	r := NewReloader(repo, c, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Listen(ctx, feed)
+
+	deadline := time.Now().Add(400 * time.Millisecond)
+	for time.Now().Before(deadline) && (c.RateLimit("k") != 8 || c.Validate("drop")) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if c.Validate("drop") || c.RateLimit("k") != 8 {
+		t.Fatalf("expected the feed's changes to be applied after the reset load")
+	}
+	if repo.calls.Load() != 1 {
+		t.Fatalf("expected the reset to load all tokens once, got %d loads", repo.calls.Load())
+	}
+	if got := testutil.ToFloat64(metrics.TokenChanges.WithLabelValues("upsert")) - upserts; got != 1 {
+		t.Fatalf("expected 1 upsert, got %v", got)
+	}
+}
+
+func TestReloader_Listen_RetriesFailedFeed(t *testing.T) {
+	r := NewReloader(fakeRepo{}, NewCache(), time.Hour)
+	r.listenBackoff = time.Millisecond
+	feed := &fakeFeed{err: errors.New("connection refused")}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Listen(ctx, feed)
+
+	deadline := time.Now().Add(400 * time.Millisecond)
+	for time.Now().Before(deadline) && feed.watches.Load() < 3 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := feed.watches.Load(); got < 3 {
+		t.Fatalf("expected the feed to be watched again after failures, got %d watches", got)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"pdf-renderer/internal/config"
 	"pdf-renderer/internal/http/server"
 	"pdf-renderer/internal/infra/logging"
+	"pdf-renderer/internal/infra/tracing"
 )
 
 var RedisClient *redis.Client
@@ -37,6 +38,12 @@ func main() {
 	)
 	logging.SetLogLevel(cfg.Logger.Level)
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		logging.Error("Tracing setup failed; spans will not be exported", "error", err)
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+
 	rdb := redis.NewClient(&redis.Options{
 		Addr: cfg.Cache.RedisHost,
 		DB:   cfg.Cache.PDFCacheDB,
@@ -48,6 +55,13 @@ func main() {
 	idleConnsClosed := make(chan struct{})
 	startServer(app, cfg, idleConnsClosed)
 	<-idleConnsClosed
+
+	// Flush spans still buffered by the batch exporter.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		logging.Warn("Tracing shutdown failed", "error", err)
+	}
 }
 
 // startServer starts the Fiber app and listens for shutdown signals.
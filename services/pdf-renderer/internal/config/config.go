@@ -36,6 +36,11 @@ type Config struct {
 		RedisHost       string        `yaml:"redis_host"`        // Redis server host (optional)
 		RateLimitDB     int           `yaml:"redis_rate_db"`     // Redis DB for rate limiting
 		PDFCacheDB      int           `yaml:"redis_pdf_db"`      // Redis DB for PDF caching
+
+		RenderLockEnabled bool          `yaml:"render_lock_enabled"` // Coordinate identical renders across replicas via a Redis lock
+		RenderLockTTL     time.Duration `yaml:"render_lock_ttl"`     // Lifetime of the render lock. If 0, the longest render timeout + 10s is used
+
+		HTTPCacheControl string `yaml:"http_cache_control"` // Cache-Control header sent with PDF responses (default "private, no-cache")
 	} `yaml:"cache"`
 
 	PDF struct {
@@ -47,6 +52,81 @@ type Config struct {
 		ChromePoolSize  int                  `yaml:"chrome_pool_size"`  // Number of preloaded Chrome tabs (0 = disabled)
 		UserDataDir     string               `yaml:"user_data_dir"`     // Optional fixed user data dir (recommended when pooling)
 	} `yaml:"pdf"`
+
+	Chrome struct {
+		Mode                string        `yaml:"mode"`                  // "local" launches Chromium (default); "remote" connects to remote_url
+		RemoteURL           string        `yaml:"remote_url"`            // DevTools endpoint in remote mode: ws://host:9222/devtools/browser/<id> or http://host:9222 (discovered via /json/version)
+		Processes           int           `yaml:"processes"`             // Number of Chromium processes in the pool; chrome_pool_size tabs are split across them (default 1)
+		RestartDrainTimeout time.Duration `yaml:"restart_drain_timeout"` // How long a restart waits for in-flight renders before terminating them (default timeout_secs)
+
+		Launch struct {
+			ExtraFlags      []string `yaml:"extra_flags"`       // Additional Chromium flags ("--name=value" or "--name"); they override built-in ones, "--name=false" removes one
+			ProxyServer     string   `yaml:"proxy_server"`      // Proxy for page and sub-resource requests, e.g. http://proxy:3128 or socks5://proxy:1080
+			ProxyBypassList string   `yaml:"proxy_bypass_list"` // Hosts reached directly, separated by ';' (e.g. "localhost;*.internal")
+			UserAgent       string   `yaml:"user_agent"`        // Replaces Chromium's User-Agent header
+			AcceptLanguage  string   `yaml:"accept_language"`   // Accept-Language header and navigator.languages, e.g. "de-DE,de,en"
+			WindowSize      string   `yaml:"window_size"`       // Window (layout viewport) size, "<width>x<height>", e.g. 1280x1024
+		} `yaml:"launch"` // Local mode only; a remote Chromium is configured where it is started
+
+		Recycle struct {
+			MaxRenders    int           `yaml:"max_renders"`    // Recycle a process after this many renders (0 = unlimited)
+			MaxAge        time.Duration `yaml:"max_age"`        // Recycle a process after it has been running this long (0 = unlimited)
+			MaxRSSMB      int           `yaml:"max_rss_mb"`     // Recycle a process once its RSS, including child processes, exceeds this many MB (0 = unlimited)
+			CheckInterval time.Duration `yaml:"check_interval"` // How often age and RSS are checked (default 30s)
+		} `yaml:"recycle"`
+
+		Probe struct {
+			Enabled          bool          `yaml:"enabled"`           // Periodically render a trivial page on every process
+			Interval         time.Duration `yaml:"interval"`          // Time between probes (default 15s)
+			Timeout          time.Duration `yaml:"timeout"`           // Deadline for a single probe (default 5s)
+			FailureThreshold int           `yaml:"failure_threshold"` // Consecutive failures before a process is marked unhealthy and restarted (default 3)
+		} `yaml:"probe"`
+	} `yaml:"chrome"`
+
+	Scheduler struct {
+		QueueDepth             int           `yaml:"queue_depth"`              // Max renders waiting for a free tab across all tenants (0 = unlimited)
+		TenantQueueDepth       int           `yaml:"tenant_queue_depth"`       // Max renders a single tenant may have waiting (0 = unlimited)
+		InteractiveWaitTimeout time.Duration `yaml:"interactive_wait_timeout"` // Max queueing time for interactive renders (default 5s)
+		BatchWaitTimeout       time.Duration `yaml:"batch_wait_timeout"`       // Max queueing time for batch renders (default 60s)
+		InteractiveScopes      []string      `yaml:"interactive_scopes"`       // API key scopes allowed the interactive priority; "*" allows everyone, other callers run as batch
+	} `yaml:"scheduler"`
+
+	Render struct {
+		MaxTimeoutSecs int `yaml:"max_timeout_secs"` // Upper bound for the timeout parameter (default pdf.timeout_secs)
+
+		DiagnosticsEnabled bool     `yaml:"diagnostics_enabled"`  // Allow the debug parameter (console, exception and network diagnostics); disable in production
+		DiagnosticsScopes  []string `yaml:"diagnostics_scopes"`   // API key scopes allowed to use the debug parameter; "*" allows everyone, empty forbids it
+		DisableJavaScript  bool     `yaml:"disable_javascript"`   // Default for the javascript parameter: render without executing page scripts
+		BlockResourceTypes []string `yaml:"block_resource_types"` // Resource types never loaded, in addition to the block parameter (image, font, stylesheet, media, script, xhr, fetch)
+		BlockURLPatterns   []string `yaml:"block_url_patterns"`   // URL patterns never loaded ('*' and '?' wildcards), in addition to the block_urls parameter
+		BlockTrackers      bool     `yaml:"block_trackers"`       // Block the built-in list of analytics and ad domains
+
+		FontsDir            string `yaml:"fonts_dir"`             // Directory of .ttf/.otf/.woff/.woff2 files made available to every render
+		ReportFontFallbacks bool   `yaml:"report_font_fallbacks"` // Log (and report with debug) font families that fell back to another font
+
+		MaxInjectCSSBytes int      `yaml:"max_inject_css_bytes"` // Size limit of the css parameter (default 64 KiB)
+		MaxInjectJSBytes  int      `yaml:"max_inject_js_bytes"`  // Size limit of the js parameter (default 16 KiB)
+		InjectJSScopes    []string `yaml:"inject_js_scopes"`     // API key scopes allowed to use the js parameter; "*" allows everyone, empty forbids it
+	} `yaml:"render"`
+
+	AssetCache struct {
+		Enabled          bool          `yaml:"enabled"`            // Serve stylesheets, scripts, fonts and images from a local disk cache
+		Dir              string        `yaml:"dir"`                // Cache directory (default /tmp/html2pdf-assets)
+		MaxSizeMB        int           `yaml:"max_size_mb"`        // Total size of cached assets; least recently used ones are evicted (default 256)
+		SeedDir          string        `yaml:"seed_dir"`           // Optional directory laid out as <host>/<path> whose files are always served
+		Offline          bool          `yaml:"offline"`            // Never fetch sub-resources; uncached ones fail
+		DefaultTTL       time.Duration `yaml:"default_ttl"`        // Freshness of responses without Cache-Control max-age or Expires (default 24h)
+		FetchTimeoutSecs int           `yaml:"fetch_timeout_secs"` // Timeout per asset download (default 10)
+	} `yaml:"asset_cache"`
+
+	Tracing struct {
+		Enabled     bool    `yaml:"enabled"`      // Export OpenTelemetry traces (incoming traceparent headers are honoured either way)
+		Exporter    string  `yaml:"exporter"`     // "otlp" (OTLP over HTTP, default) or "stdout"
+		Endpoint    string  `yaml:"endpoint"`     // OTLP/HTTP collector host:port (default localhost:4318)
+		Insecure    bool    `yaml:"insecure"`     // Send OTLP over plain HTTP instead of HTTPS
+		SampleRatio float64 `yaml:"sample_ratio"` // Fraction of new traces to sample (default 1); sampled parent traces are always kept
+		ServiceName string  `yaml:"service_name"` // service.name reported to the collector (default "html2pdf")
+	} `yaml:"tracing"`
 }
 
 // PaperSize defines width and height in inches for a specific paper format.
@@ -70,7 +150,7 @@ func Load() Config {
 }
 
 // LoadFrom loads the configuration from the specified YAML file path.
-// Panics if the file cannot be read or the format is invalid.
+// Panics if the file cannot be read, the format is invalid or settings contradict each other.
 func LoadFrom(path string) Config {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -81,6 +161,10 @@ func LoadFrom(path string) Config {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		panic("Invalid YAML format in " + path + ": " + err.Error())
 	}
+	if cfg.AssetCache.Offline && !cfg.AssetCache.Enabled {
+		// Offline mode is enforced by the asset cache; without it renders would reach the network.
+		panic("Invalid config in " + path + ": asset_cache.offline requires asset_cache.enabled")
+	}
 
 	mu.Lock()
 	AppConfig = cfg
@@ -141,6 +141,17 @@ server:
 	})
 }
 
+func TestLoadConfigFrom_OfflineAssetCacheRequiresEnabled(t *testing.T) {
+	tmp := writeTempConfig(t, `
+asset_cache:
+  enabled: false
+  offline: true
+`)
+	assert.Panics(t, func() {
+		LoadFrom(tmp)
+	})
+}
+
 func TestLoadConfigFrom_MissingSection(t *testing.T) {
 	yaml := `
 server:
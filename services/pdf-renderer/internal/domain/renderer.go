@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"pdf-renderer/internal/config"
+)
+
+// Render priority classes. Interactive renders are scheduled before batch renders.
+const (
+	PriorityInteractive = "interactive"
+	PriorityBatch       = "batch"
+)
+
+// RenderRequest describes a single document to render. Exactly one of HTML or URL is set.
+type RenderRequest struct {
+	HTML   string
+	URL    string
+	Paper  config.PaperSize
+	Margin float64 // inches, applied to all sides
+
+	Tenant   string        // fairness key for scheduling (caller identity)
+	Priority string        // PriorityInteractive (default) or PriorityBatch
+	Timeout  time.Duration // render deadline once a tab is acquired; 0 uses pdf.timeout_secs
+
+	Policy ResourcePolicy
+
+	// Injected into the document once it has loaded, before the render-ready checks.
+	CSS    string // extra style sheet, e.g. print styles or rules hiding cookie banners
+	Script string // JavaScript run in the page; it may use await
+}
+
+// ResourcePolicy restricts what a page may execute or load while it is rendered.
+type ResourcePolicy struct {
+	DisableJavaScript bool     // do not execute page scripts
+	BlockTypes        []string // resource types never loaded (image, font, stylesheet, media, script, xhr, fetch)
+	BlockURLs         []string // URL patterns never loaded; '*' matches any sequence, '?' one character
+	BlockTrackers     bool     // also block the built-in list of analytics and ad domains
+}
+
+// RendererStats is a lightweight snapshot of a renderer's capacity.
+type RendererStats struct {
+	Backend  string `json:"backend"`
+	Capacity int    `json:"capacity"`
+	InUse    int    `json:"in_use"`
+}
+
+// Renderer turns HTML or a URL into PDF bytes.
+// Implementations must be safe for concurrent use.
+type Renderer interface {
+	Render(ctx context.Context, req RenderRequest) ([]byte, error)
+	Stats() RendererStats
+	Close() error
+}
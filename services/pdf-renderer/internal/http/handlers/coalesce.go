@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// renderCall is a single in-flight render shared by every request with the same cache key.
+type renderCall struct {
+	done    chan struct{}
+	buf     []byte
+	err     error
+	waiters int                // requests still waiting for the result
+	cancel  context.CancelFunc // cancels the render once no request waits for it
+}
+
+// renderGroup deduplicates concurrent renders of identical requests within this process.
+// The first caller for a key (the leader) starts the render; followers join it and every
+// caller receives the same result.
+type renderGroup struct {
+	mu    sync.Mutex
+	calls map[string]*renderCall
+}
+
+// Do runs fn once per in-flight key. shared reports whether the result was produced by another
+// caller. The render runs in a context carrying the leader's values but not its cancellation: it
+// is cancelled only when every caller waiting for it has given up (its ctx is done), in which
+// case each of them gets its ctx.Err().
+func (g *renderGroup) Do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) (buf []byte, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*renderCall)
+	}
+	call, shared := g.calls[key]
+	if !shared {
+		renderCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &renderCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go func() {
+			buf, err := fn(renderCtx)
+			g.mu.Lock()
+			call.buf, call.err = buf, err
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			cancel()
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.buf, shared, call.err
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	call.waiters--
+	if call.waiters == 0 {
+		call.cancel()
+		// New requests start a fresh render rather than joining the cancelled one.
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+	}
+	g.mu.Unlock()
+	return nil, shared, ctx.Err()
+}
+
+// releaseRenderLockScript deletes the lock only if it is still owned by the caller.
+var releaseRenderLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renderLockKey derives the Redis lock key guarding a render across replicas.
+func renderLockKey(cacheKey string) string {
+	return "pdflock:" + strings.TrimPrefix(cacheKey, "pdfcache:")
+}
+
+// acquireRenderLock tries to become the cluster-wide leader for a render.
+func acquireRenderLock(ctx context.Context, rdb *redis.Client, lockKey, owner string, ttl time.Duration) (bool, error) {
+	ctxRedis, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+	return rdb.SetNX(ctxRedis, lockKey, owner, ttl).Result()
+}
+
+// releaseRenderLock releases a lock previously acquired by owner.
+func releaseRenderLock(ctx context.Context, rdb *redis.Client, lockKey, owner string) error {
+	ctxRedis, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+	return releaseRenderLockScript.Run(ctxRedis, rdb, []string{lockKey}, owner).Err()
+}
+
+// waitForCachedPDF polls Redis until another replica has cached the PDF for cacheKey.
+// It returns (nil, nil) when the lock disappears without a cached result (the leader failed)
+// and ctx.Err() when ctx expires first.
+func waitForCachedPDF(ctx context.Context, rdb *redis.Client, cacheKey, lockKey string, interval time.Duration) ([]byte, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		cached, err := rdb.Get(ctx, cacheKey).Bytes()
+		if err == nil {
+			return cached, nil
+		}
+		if err != redis.Nil {
+			return nil, err
+		}
+
+		exists, err := rdb.Exists(ctx, lockKey).Result()
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			// The lock may have been released between the two reads; check the cache once more.
+			cached, err := rdb.Get(ctx, cacheKey).Bytes()
+			if err == nil {
+				return cached, nil
+			}
+			if err != redis.Nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRenderGroup_SharesInFlightResult(t *testing.T) {
+	var g renderGroup
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	leaderStarted := make(chan struct{})
+	leaderDone := make(chan struct{})
+	var leaderBuf []byte
+	go func() {
+		defer close(leaderDone)
+		leaderBuf, _, _ = g.Do(context.Background(), "k", func(context.Context) ([]byte, error) {
+			calls.Add(1)
+			close(leaderStarted)
+			<-release
+			return []byte("pdf"), nil
+		})
+	}()
+	<-leaderStarted
+
+	const followers = 5
+	var wg sync.WaitGroup
+	var sharedCount atomic.Int32
+	for i := 0; i < followers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf, shared, err := g.Do(context.Background(), "k", func(context.Context) ([]byte, error) {
+				calls.Add(1)
+				return []byte("other"), nil
+			})
+			if err != nil || string(buf) != "pdf" {
+				t.Errorf("expected shared pdf, got %q (%v)", buf, err)
+			}
+			if shared {
+				sharedCount.Add(1)
+			}
+		}()
+	}
+
+	waitForWaiters(t, &g, "k", followers+1)
+	close(release)
+	wg.Wait()
+	<-leaderDone
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected exactly one render, got %d", got)
+	}
+	if got := sharedCount.Load(); got != followers {
+		t.Fatalf("expected %d shared results, got %d", followers, got)
+	}
+	if string(leaderBuf) != "pdf" {
+		t.Fatalf("unexpected leader result %q", leaderBuf)
+	}
+
+	// Once finished, the key is free again.
+	buf, shared, err := g.Do(context.Background(), "k", func(context.Context) ([]byte, error) { return []byte("fresh"), nil })
+	if err != nil || shared || string(buf) != "fresh" {
+		t.Fatalf("expected fresh render after completion, got %q shared=%v err=%v", buf, shared, err)
+	}
+}
+
+func TestRenderGroup_PropagatesError(t *testing.T) {
+	var g renderGroup
+	wantErr := errors.New("boom")
+	_, shared, err := g.Do(context.Background(), "k", func(context.Context) ([]byte, error) { return nil, wantErr })
+	if !errors.Is(err, wantErr) || shared {
+		t.Fatalf("expected leader error, got %v shared=%v", err, shared)
+	}
+}
+
+func TestRenderLock_AcquireReleaseOwnership(t *testing.T) {
+	mrs, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer mrs.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mrs.Addr()})
+	ctx := context.Background()
+
+	lockKey := renderLockKey("pdfcache:abc")
+	if lockKey != "pdflock:abc" {
+		t.Fatalf("unexpected lock key %q", lockKey)
+	}
+
+	ok, err := acquireRenderLock(ctx, rdb, lockKey, "a", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("expected first acquire to succeed, got %v %v", ok, err)
+	}
+	ok, err = acquireRenderLock(ctx, rdb, lockKey, "b", time.Minute)
+	if err != nil || ok {
+		t.Fatalf("expected second acquire to fail, got %v %v", ok, err)
+	}
+
+	// A non-owner must not release the lock.
+	if err := releaseRenderLock(ctx, rdb, lockKey, "b"); err != nil {
+		t.Fatalf("release by non-owner: %v", err)
+	}
+	if !mrs.Exists(lockKey) {
+		t.Fatalf("expected lock to survive release by non-owner")
+	}
+
+	if err := releaseRenderLock(ctx, rdb, lockKey, "a"); err != nil {
+		t.Fatalf("release by owner: %v", err)
+	}
+	if mrs.Exists(lockKey) {
+		t.Fatalf("expected lock to be released by owner")
+	}
+}
+
+func TestWaitForCachedPDF(t *testing.T) {
+	mrs, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer mrs.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mrs.Addr()})
+
+	t.Run("result cached by leader", func(t *testing.T) {
+		_ = mrs.Set("pdflock:1", "leader")
+		go func() {
+			waitForPoll(mrs)
+			_ = mrs.Set("pdfcache:1", "shared-pdf")
+			mrs.Del("pdflock:1")
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		buf, err := waitForCachedPDF(ctx, rdb, "pdfcache:1", "pdflock:1", 10*time.Millisecond)
+		if err != nil || string(buf) != "shared-pdf" {
+			t.Fatalf("expected shared pdf, got %q (%v)", buf, err)
+		}
+	})
+
+	t.Run("leader gave up", func(t *testing.T) {
+		_ = mrs.Set("pdflock:2", "leader")
+		go func() {
+			waitForPoll(mrs)
+			mrs.Del("pdflock:2")
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		buf, err := waitForCachedPDF(ctx, rdb, "pdfcache:2", "pdflock:2", 10*time.Millisecond)
+		if err != nil || buf != nil {
+			t.Fatalf("expected no result without error, got %q (%v)", buf, err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_ = mrs.Set("pdflock:3", "leader")
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := waitForCachedPDF(ctx, rdb, "pdfcache:3", "pdflock:3", 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+	})
+}
+
+func TestRenderGroup_CancelledWhenEveryCallerLeaves(t *testing.T) {
+	var g renderGroup
+	started := make(chan struct{})
+	renderErr := make(chan error, 1)
+	render := func(ctx context.Context) ([]byte, error) {
+		close(started)
+		<-ctx.Done()
+		renderErr <- ctx.Err()
+		return nil, ctx.Err()
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	followerCtx, cancelFollower := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, _, err := g.Do(leaderCtx, "k", render)
+		leaderDone <- err
+	}()
+	<-started
+	followerDone := make(chan error, 1)
+	go func() {
+		_, _, err := g.Do(followerCtx, "k", render)
+		followerDone <- err
+	}()
+	waitForWaiters(t, &g, "k", 2)
+
+	// The leader leaving does not stop a render another request still waits for.
+	cancelLeader()
+	if err := <-leaderDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the leader to get context.Canceled, got %v", err)
+	}
+	select {
+	case err := <-renderErr:
+		t.Fatalf("render cancelled while a follower was waiting: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancelFollower()
+	if err := <-followerDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the follower to get context.Canceled, got %v", err)
+	}
+	select {
+	case <-renderErr:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the render to be cancelled once nobody waits for it")
+	}
+
+	// An abandoned render is not joined by new requests.
+	buf, shared, err := g.Do(context.Background(), "k", func(context.Context) ([]byte, error) { return []byte("fresh"), nil })
+	if err != nil || shared || string(buf) != "fresh" {
+		t.Fatalf("expected a fresh render, got %q shared=%v err=%v", buf, shared, err)
+	}
+}
+
+// waitForWaiters blocks until n callers wait for the in-flight render of key.
+func waitForWaiters(t *testing.T, g *renderGroup, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		g.mu.Lock()
+		got := 0
+		if call := g.calls[key]; call != nil {
+			got = call.waiters
+		}
+		g.mu.Unlock()
+		if got >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d waiters for %q, got %d", n, key, got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitForPoll blocks until mrs has served a command issued after the call, i.e. the waiter has
+// polled at least once while the lock was held.
+func waitForPoll(mrs *miniredis.Miniredis) {
+	for seen := mrs.CommandCount(); mrs.CommandCount() == seen; {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/config"
+)
+
+// Debug modes (the "debug" request parameter).
+const (
+	debugWarnings = "warnings" // render normally and summarize diagnostics in X-Render-Warnings
+	debugReport   = "report"   // answer with a JSON diagnostics report instead of the PDF
+)
+
+const (
+	maxDiagnosticEntries = 50  // per category; further entries only set Truncated
+	maxDiagnosticText    = 500 // characters kept per message or URL
+
+	// blockedByClientError is how Chrome reports loads failed by the resource policy (see resources.go).
+	blockedByClientError = "net::ERR_BLOCKED_BY_CLIENT"
+)
+
+// renderDiagnostics collects what the browser reported while rendering one document: console
+// messages, uncaught exceptions, failed sub-requests and blocked resources. It travels in the
+// request context; renderers attach it to their tab. All methods are no-ops on a nil receiver.
+type renderDiagnostics struct {
+	mu     sync.Mutex
+	urls   map[network.RequestID]string // request URLs, to name failed loads
+	report diagnosticsReport
+}
+
+type diagnosticsReport struct {
+	Console        []consoleEntry    `json:"console"`
+	Exceptions     []exceptionEntry  `json:"exceptions"`
+	FailedRequests []failedRequest   `json:"failed_requests"`
+	Blocked        []blockedResource `json:"blocked_resources"`
+	FontFallbacks  []fontFallback    `json:"font_fallbacks"` // with render.report_font_fallbacks
+	Truncated      bool              `json:"truncated"`      // some entries were dropped (see maxDiagnosticEntries)
+}
+
+type consoleEntry struct {
+	Level  string `json:"level"`
+	Source string `json:"source"` // "console" for console.* calls, else the browser log source (network, security, ...)
+	Text   string `json:"text"`
+	URL    string `json:"url,omitempty"`
+}
+
+type exceptionEntry struct {
+	Message string `json:"message"`
+	URL     string `json:"url,omitempty"`
+	Line    int64  `json:"line,omitempty"` // 1-based
+	Column  int64  `json:"column,omitempty"`
+}
+
+type failedRequest struct {
+	URL    string `json:"url"`
+	Status int64  `json:"status,omitempty"` // HTTP status for responses >= 400
+	Error  string `json:"error,omitempty"`  // network error for loads that failed
+}
+
+type blockedResource struct {
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+type fontFallback struct {
+	Family string   `json:"family"` // computed font-family list whose first family was not used
+	Used   []string `json:"used"`   // platform fonts Chrome drew the text with
+}
+
+type renderDiagnosticsKey struct{}
+
+// withRenderDiagnostics returns ctx carrying a new diagnostics collector.
+func withRenderDiagnostics(ctx context.Context) (context.Context, *renderDiagnostics) {
+	d := &renderDiagnostics{urls: make(map[network.RequestID]string)}
+	return context.WithValue(ctx, renderDiagnosticsKey{}, d), d
+}
+
+// diagnosticsFrom returns the collector carried by ctx, or nil.
+func diagnosticsFrom(ctx context.Context) *renderDiagnostics {
+	d, _ := ctx.Value(renderDiagnosticsKey{}).(*renderDiagnostics)
+	return d
+}
+
+// handleEvent records a target event. It runs on chromedp's event loop and must not block.
+func (d *renderDiagnostics) handleEvent(ev any) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r := &d.report
+	switch ev := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		r.Truncated = appendCapped(&r.Console, consoleEntry{
+			Level:  consoleLevel(ev.Type),
+			Source: "console",
+			Text:   clip(formatConsoleArgs(ev.Args)),
+		}) || r.Truncated
+	case *cdplog.EventEntryAdded:
+		if ev.Entry == nil || (ev.Entry.Level != cdplog.LevelWarning && ev.Entry.Level != cdplog.LevelError) {
+			return
+		}
+		r.Truncated = appendCapped(&r.Console, consoleEntry{
+			Level:  ev.Entry.Level.String(),
+			Source: ev.Entry.Source.String(),
+			Text:   clip(ev.Entry.Text),
+			URL:    clip(ev.Entry.URL),
+		}) || r.Truncated
+	case *runtime.EventExceptionThrown:
+		details := ev.ExceptionDetails
+		if details == nil {
+			return
+		}
+		msg := details.Text
+		if details.Exception != nil && details.Exception.Description != "" {
+			msg = details.Exception.Description
+		}
+		r.Truncated = appendCapped(&r.Exceptions, exceptionEntry{
+			Message: clip(msg),
+			URL:     clip(details.URL),
+			Line:    details.LineNumber + 1,
+			Column:  details.ColumnNumber + 1,
+		}) || r.Truncated
+	case *network.EventRequestWillBeSent:
+		if ev.Request != nil {
+			d.urls[ev.RequestID] = ev.Request.URL
+		}
+	case *network.EventResponseReceived:
+		if ev.Response != nil && ev.Response.Status >= 400 {
+			r.Truncated = appendCapped(&r.FailedRequests, failedRequest{
+				URL:    clip(ev.Response.URL),
+				Status: ev.Response.Status,
+			}) || r.Truncated
+		}
+	case *network.EventLoadingFailed:
+		url := clip(d.urls[ev.RequestID])
+		switch {
+		case ev.BlockedReason != "":
+			r.Truncated = appendCapped(&r.Blocked, blockedResource{URL: url, Reason: ev.BlockedReason.String()}) || r.Truncated
+		case ev.ErrorText == blockedByClientError:
+			r.Truncated = appendCapped(&r.Blocked, blockedResource{URL: url, Reason: "policy"}) || r.Truncated
+		case ev.CorsErrorStatus != nil:
+			r.Truncated = appendCapped(&r.Blocked, blockedResource{URL: url, Reason: "cors: " + ev.CorsErrorStatus.CorsError.String()}) || r.Truncated
+		case !ev.Canceled:
+			r.Truncated = appendCapped(&r.FailedRequests, failedRequest{URL: url, Error: ev.ErrorText}) || r.Truncated
+		}
+	}
+}
+
+// addFontFallback records a font family that fell back (see checkFontFallbacks).
+func (d *renderDiagnostics) addFontFallback(f fontFallback) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.report.Truncated = appendCapped(&d.report.FontFallbacks, f) || d.report.Truncated
+}
+
+// snapshot returns a copy of the report with empty (not null) lists.
+func (d *renderDiagnostics) snapshot() diagnosticsReport {
+	if d == nil {
+		return diagnosticsReport{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r := d.report
+	r.Console = append([]consoleEntry{}, r.Console...)
+	r.Exceptions = append([]exceptionEntry{}, r.Exceptions...)
+	r.FailedRequests = append([]failedRequest{}, r.FailedRequests...)
+	r.Blocked = append([]blockedResource{}, r.Blocked...)
+	r.FontFallbacks = append([]fontFallback{}, r.FontFallbacks...)
+	return r
+}
+
+// summary formats the counts for the X-Render-Warnings header.
+func (r diagnosticsReport) summary() string {
+	var errs, warnings int
+	for _, e := range r.Console {
+		switch e.Level {
+		case "error":
+			errs++
+		case "warning":
+			warnings++
+		}
+	}
+	s := fmt.Sprintf("console_errors=%d, console_warnings=%d, exceptions=%d, failed_requests=%d, blocked=%d",
+		errs, warnings, len(r.Exceptions), len(r.FailedRequests), len(r.Blocked))
+	if len(r.FontFallbacks) > 0 {
+		s += fmt.Sprintf(", font_fallbacks=%d", len(r.FontFallbacks))
+	}
+	if r.Truncated {
+		s += ", truncated"
+	}
+	return s
+}
+
+// extractDebug reads the "debug" parameter: "1"/"true"/"warnings" or "report". Debugging must be
+// enabled with render.diagnostics_enabled and is allowed only to callers holding one of the
+// render.diagnostics_scopes, as debug renders bypass the cache and expose sub-request outcomes.
+func extractDebug(c *fiber.Ctx, cfg config.Config) (string, error) {
+	var mode string
+	switch v := strings.ToLower(strings.TrimSpace(c.FormValue("debug"))); v {
+	case "", "0", "false":
+		return "", nil
+	case "1", "true", debugWarnings:
+		mode = debugWarnings
+	case debugReport:
+		mode = debugReport
+	default:
+		return "", fiber.NewError(fiber.StatusBadRequest, "Invalid debug: must be 'warnings' or 'report'")
+	}
+	if !cfg.Render.DiagnosticsEnabled {
+		return "", fiber.NewError(fiber.StatusForbidden, "Render diagnostics are disabled")
+	}
+	if !scopeAllowed(c, cfg.Render.DiagnosticsScopes) {
+		return "", fiber.NewError(fiber.StatusForbidden, "Render diagnostics are not allowed for this API key")
+	}
+	return mode, nil
+}
+
+// appendCapped appends e unless the list is full; it reports whether e was dropped.
+func appendCapped[T any](list *[]T, e T) bool {
+	if len(*list) >= maxDiagnosticEntries {
+		return true
+	}
+	*list = append(*list, e)
+	return false
+}
+
+func consoleLevel(t runtime.APIType) string {
+	switch t {
+	case runtime.APITypeError, runtime.APITypeAssert:
+		return "error"
+	case runtime.APITypeWarning:
+		return "warning"
+	case runtime.APITypeDebug:
+		return "debug"
+	default:
+		return "info"
+	}
+}
+
+// formatConsoleArgs renders console.* arguments roughly like the DevTools console does.
+func formatConsoleArgs(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		switch {
+		case len(arg.Value) > 0:
+			var s string
+			if err := json.Unmarshal(arg.Value, &s); err == nil {
+				parts = append(parts, s)
+			} else {
+				parts = append(parts, string(arg.Value))
+			}
+		case arg.UnserializableValue != "":
+			parts = append(parts, arg.UnserializableValue.String())
+		case arg.Description != "":
+			parts = append(parts, arg.Description)
+		default:
+			parts = append(parts, arg.Type.String())
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func clip(s string) string {
+	if len(s) <= maxDiagnosticText {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxDiagnosticText], "") + "…"
+}
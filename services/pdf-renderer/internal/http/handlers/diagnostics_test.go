@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/infra/fake"
+)
+
+func TestRenderDiagnostics_HandleEvent(t *testing.T) {
+	_, d := withRenderDiagnostics(t.Context())
+
+	d.handleEvent(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeError,
+		Args: []*runtime.RemoteObject{
+			{Type: runtime.TypeString, Value: []byte(`"chart failed:"`)},
+			{Type: runtime.TypeNumber, Value: []byte(`42`)},
+		},
+	})
+	d.handleEvent(&runtime.EventConsoleAPICalled{Type: runtime.APITypeLog, Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Value: []byte(`"hello"`)}}})
+	d.handleEvent(&cdplog.EventEntryAdded{Entry: &cdplog.Entry{Source: cdplog.SourceNetwork, Level: cdplog.LevelError, Text: "Failed to load resource", URL: "https://cdn.example/x.css"}})
+	d.handleEvent(&cdplog.EventEntryAdded{Entry: &cdplog.Entry{Source: cdplog.SourceOther, Level: cdplog.LevelVerbose, Text: "noise"}})
+	d.handleEvent(&runtime.EventExceptionThrown{ExceptionDetails: &runtime.ExceptionDetails{
+		Text:       "Uncaught",
+		Exception:  &runtime.RemoteObject{Description: "ReferenceError: foo is not defined"},
+		URL:        "https://example.com/app.js",
+		LineNumber: 9,
+	}})
+	d.handleEvent(&network.EventRequestWillBeSent{RequestID: "1", Request: &network.Request{URL: "https://fonts.example/font.woff2"}})
+	d.handleEvent(&network.EventLoadingFailed{RequestID: "1", ErrorText: "net::ERR_NAME_NOT_RESOLVED"})
+	d.handleEvent(&network.EventRequestWillBeSent{RequestID: "2", Request: &network.Request{URL: "https://ads.example/a.js"}})
+	d.handleEvent(&network.EventLoadingFailed{RequestID: "2", BlockedReason: network.BlockedReasonMixedContent})
+	d.handleEvent(&network.EventLoadingFailed{RequestID: "3", Canceled: true})
+	d.handleEvent(&network.EventResponseReceived{Response: &network.Response{URL: "https://example.com/missing.png", Status: 404}})
+	d.handleEvent(&network.EventResponseReceived{Response: &network.Response{URL: "https://example.com/ok.png", Status: 200}})
+
+	r := d.snapshot()
+	if len(r.Console) != 3 || r.Console[0].Text != "chart failed: 42" || r.Console[0].Level != "error" || r.Console[2].Source != "network" {
+		t.Fatalf("unexpected console entries %+v", r.Console)
+	}
+	if len(r.Exceptions) != 1 || r.Exceptions[0].Message != "ReferenceError: foo is not defined" || r.Exceptions[0].Line != 10 {
+		t.Fatalf("unexpected exceptions %+v", r.Exceptions)
+	}
+	if len(r.FailedRequests) != 2 || r.FailedRequests[0].URL != "https://fonts.example/font.woff2" || r.FailedRequests[1].Status != 404 {
+		t.Fatalf("unexpected failed requests %+v", r.FailedRequests)
+	}
+	if len(r.Blocked) != 1 || r.Blocked[0].Reason != "mixed-content" {
+		t.Fatalf("unexpected blocked resources %+v", r.Blocked)
+	}
+
+	want := "console_errors=2, console_warnings=0, exceptions=1, failed_requests=2, blocked=1"
+	if got := r.summary(); got != want {
+		t.Fatalf("unexpected summary %q", got)
+	}
+}
+
+func TestRenderDiagnostics_CapsEntries(t *testing.T) {
+	_, d := withRenderDiagnostics(t.Context())
+	long := strings.Repeat("x", 2*maxDiagnosticText)
+	for range maxDiagnosticEntries + 5 {
+		d.handleEvent(&runtime.EventConsoleAPICalled{Type: runtime.APITypeWarning, Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Description: long}}})
+	}
+	r := d.snapshot()
+	if len(r.Console) != maxDiagnosticEntries || !r.Truncated {
+		t.Fatalf("expected %d entries and truncation, got %d (truncated=%v)", maxDiagnosticEntries, len(r.Console), r.Truncated)
+	}
+	if n := len([]rune(r.Console[0].Text)); n != maxDiagnosticText+1 {
+		t.Fatalf("expected clipped text, got %d runes", n)
+	}
+	if !strings.HasSuffix(r.summary(), ", truncated") {
+		t.Fatalf("expected truncation in summary %q", r.summary())
+	}
+}
+
+func TestHandleURLConversion_Debug(t *testing.T) {
+	svc, app := newFakeService(t, fake.NewRenderer())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/pdf?url=https://example.com&debug=report", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusForbidden {
+		t.Fatalf("expected 403 while diagnostics are disabled, got %d", resp.StatusCode)
+	}
+
+	svc.Config.Render.DiagnosticsEnabled = true
+	svc.Config.Render.DiagnosticsScopes = []string{"ops"}
+
+	for _, scopes := range []string{"", "api"} {
+		req := httptest.NewRequest("GET", "/pdf?url=https://example.com&debug=report", nil)
+		if scopes != "" {
+			req.Header.Set("X-Auth-Mode", "token")
+			req.Header.Set("X-Auth-Scopes", scopes)
+		}
+		resp, err = app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusForbidden {
+			t.Fatalf("expected 403 without the diagnostics scope (scopes %q), got %d", scopes, resp.StatusCode)
+		}
+	}
+
+	svc.Config.Render.DiagnosticsScopes = []string{"*"}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/pdf?url=https://example.com&debug=report", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var body struct {
+		Diagnostics diagnosticsReport `json:"diagnostics"`
+		PDFBytes    int               `json:"pdf_bytes"`
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("invalid report %s: %v", raw, err)
+	}
+	if body.PDFBytes == 0 || body.Diagnostics.Console == nil {
+		t.Fatalf("unexpected report %s", raw)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/pdf?url=https://example.com&debug=1", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Fatalf("expected PDF response, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if got := resp.Header.Get("X-Render-Warnings"); !strings.HasPrefix(got, "console_errors=0") {
+		t.Fatalf("unexpected X-Render-Warnings %q", got)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/pdf?url=https://example.com&debug=verbose", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown debug mode, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleURLConversion_DebugReportIncludesRenderError(t *testing.T) {
+	svc, app := newFakeService(t, &fake.Renderer{Err: errors.New("render exploded")})
+	svc.Config.Render.DiagnosticsEnabled = true
+	svc.Config.Render.DiagnosticsScopes = []string{"ops"}
+
+	req := httptest.NewRequest("GET", "/pdf?url=https://example.com&debug=report", nil)
+	req.Header.Set("X-Auth-Mode", "token")
+	req.Header.Set("X-Auth-Scopes", "api,ops")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", resp.StatusCode)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(raw), `"diagnostics"`) || !strings.Contains(string(raw), `"error"`) {
+		t.Fatalf("expected diagnostics and error in %s", raw)
+	}
+}
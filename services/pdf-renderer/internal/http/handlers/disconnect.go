@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// statusClientClosedRequest is logged for requests whose client went away before the response
+// (nginx's 499). The client never sees it.
+const statusClientClosedRequest = 499
+
+// errClientDisconnected is the cancellation cause of a request whose client closed the connection.
+var errClientDisconnected = errors.New("client disconnected")
+
+// watchDisconnect returns the request context, cancelled as soon as the client closes its
+// connection, and a function that stops watching; it must be called before the handler returns.
+// fasthttp does not read from the connection while a handler runs, so the peer closing it is
+// detected by waiting for the socket to become readable and peeking at it (see peerClosed).
+// Connections that are not sockets (e.g. in tests) are not watched.
+func watchDisconnect(c *fiber.Ctx) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(c.UserContext())
+	conn := c.Context().Conn()
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return ctx, func() { cancel(nil) }
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return ctx, func() { cancel(nil) }
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if peerClosed(raw) {
+			cancel(errClientDisconnected)
+		}
+	}()
+	return ctx, func() {
+		// Wake the watcher with an expired read deadline, then clear it for the next request.
+		_ = conn.SetReadDeadline(time.Now())
+		<-done
+		_ = conn.SetReadDeadline(time.Time{})
+		cancel(nil)
+	}
+}
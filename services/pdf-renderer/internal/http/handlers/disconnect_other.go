@@ -0,0 +1,10 @@
+//go:build !unix
+
+package handlers
+
+import "syscall"
+
+// peerClosed does not detect disconnects on this platform.
+func peerClosed(syscall.RawConn) bool {
+	return false
+}
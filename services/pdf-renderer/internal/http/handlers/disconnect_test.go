@@ -0,0 +1,117 @@
+//go:build unix
+
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/infra/fake"
+)
+
+func serveWatched(t *testing.T, handler fiber.Handler) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/", handler)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return ln.Addr().String()
+}
+
+func TestWatchDisconnect_CancelsWhenClientCloses(t *testing.T) {
+	cause := make(chan error, 1)
+	addr := serveWatched(t, func(c *fiber.Ctx) error {
+		ctx, stop := watchDisconnect(c)
+		defer stop()
+		select {
+		case <-ctx.Done():
+			cause <- context.Cause(ctx)
+		case <-time.After(5 * time.Second):
+			cause <- nil
+		}
+		return nil
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	_ = conn.Close()
+
+	if err := <-cause; !errors.Is(err, errClientDisconnected) {
+		t.Fatalf("expected the request context to be cancelled by the disconnect, got %v", err)
+	}
+}
+
+func TestWatchDisconnect_KeepAliveConnectionStaysUsable(t *testing.T) {
+	addr := serveWatched(t, func(c *fiber.Ctx) error {
+		ctx, stop := watchDisconnect(c)
+		defer stop()
+		if ctx.Err() != nil {
+			return fiber.ErrInternalServerError
+		}
+		return c.SendString("ok")
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for i := 0; i < 2; i++ {
+		if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		resp, err := http.ReadResponse(r, nil)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, resp.StatusCode)
+		}
+	}
+}
+
+func TestHandleURLConversion_ClientDisconnectAbortsRender(t *testing.T) {
+	r := &fake.Renderer{Delay: 10 * time.Second}
+	svc, _ := newFakeService(t, r)
+	addr := serveWatched(t, svc.HandleURLConversion)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("GET /?url=https://example.com HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for r.Stats().InUse == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	_ = conn.Close()
+
+	for r.Stats().InUse != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the render to be aborted when the client disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package handlers
+
+import "syscall"
+
+// peerClosed blocks until the connection becomes readable and reports whether that is because
+// the peer closed it (a zero-byte peek) or reset it. Data from the client (a pipelined request)
+// ends the watch without a disconnect; so does an expired read deadline.
+func peerClosed(raw syscall.RawConn) bool {
+	var closed bool
+	buf := make([]byte, 1)
+	err := raw.Read(func(fd uintptr) bool {
+		for {
+			n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK)
+			switch {
+			case err == syscall.EINTR:
+				continue
+			case err == syscall.EAGAIN || err == syscall.EWOULDBLOCK:
+				return false // not readable yet: wait for the poller
+			case err != nil:
+				closed = true // e.g. ECONNRESET
+			default:
+				closed = n == 0
+			}
+			return true
+		}
+	})
+	return err == nil && closed
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/domain"
+	"pdf-renderer/internal/infra/fonts"
+	"pdf-renderer/internal/infra/logging"
+	"pdf-renderer/internal/infra/tracing"
+)
+
+const (
+	maxCheckedFontFamilies = 20 // distinct font-family lists inspected per document
+	fontProbeAttr          = "data-html2pdf-font"
+)
+
+// genericFamilies never "fall back": Chrome maps them to whatever is installed.
+var genericFamilies = map[string]bool{
+	"serif": true, "sans-serif": true, "monospace": true, "cursive": true, "fantasy": true,
+	"system-ui": true, "ui-serif": true, "ui-sans-serif": true, "ui-monospace": true, "ui-rounded": true,
+	"emoji": true, "math": true, "fangsong": true, "inherit": true, "initial": true,
+}
+
+// fontProbeScript marks one text-bearing element per distinct computed font-family and returns
+// the families in mark order, so their platform fonts can be looked up through the CSS domain.
+var fontProbeScript = fmt.Sprintf(`(() => {
+	const seen = new Map();
+	if (!document.body) return [];
+	const walker = document.createTreeWalker(document.body, NodeFilter.SHOW_TEXT);
+	while (seen.size < %d && walker.nextNode()) {
+		const el = walker.currentNode.parentElement;
+		if (!el || !walker.currentNode.textContent.trim()) continue;
+		const family = getComputedStyle(el).fontFamily;
+		if (seen.has(family)) continue;
+		el.setAttribute(%q, String(seen.size));
+		seen.set(family, seen.size);
+	}
+	return [...seen.keys()];
+})()`, maxCheckedFontFamilies, fontProbeAttr)
+
+var fontProbeCleanupScript = fmt.Sprintf(`document.querySelectorAll("[%[1]s]").forEach(el => el.removeAttribute(%[1]q))`, fontProbeAttr)
+
+// resolvedFont is a font-family list as used in the document and the platform fonts Chrome drew
+// its text with.
+type resolvedFont struct {
+	Family        string         `json:"family"`
+	PlatformFonts []platformFont `json:"platform_fonts"`
+	Fallback      bool           `json:"fallback"` // the first family was not used
+}
+
+type platformFont struct {
+	Name           string  `json:"name"`
+	PostScriptName string  `json:"postscript_name,omitempty"`
+	Custom         bool    `json:"custom"` // a web font (@font-face), e.g. from the fonts directory
+	Glyphs         float64 `json:"glyphs"`
+}
+
+// injectFonts adds the @font-face rules of lib to the current document.
+func injectFonts(lib *fonts.Library) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if lib == nil || lib.CSS() == "" {
+			return nil
+		}
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		id, err := css.CreateStyleSheet(tree.Frame.ID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := css.SetStyleSheetText(id, lib.CSS()).Do(ctx); err != nil {
+			return err
+		}
+		// Force a layout so the fonts in use start loading before the render-ready checks.
+		return chromedp.Evaluate(`document.body ? document.body.offsetHeight : 0`, nil).Do(ctx)
+	})
+}
+
+// resolveFonts reports, for each distinct font-family list the document's text uses, which
+// platform fonts rendered it.
+func resolveFonts(ctx context.Context) ([]resolvedFont, error) {
+	var families []string
+	if err := chromedp.Evaluate(fontProbeScript, &families).Do(ctx); err != nil {
+		return nil, err
+	}
+	defer func() { _ = chromedp.Evaluate(fontProbeCleanupScript, nil).Do(ctx) }()
+
+	root, err := dom.GetDocument().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make([]resolvedFont, 0, len(families))
+	for i, family := range families {
+		nodeID, err := dom.QuerySelector(root.NodeID, fmt.Sprintf(`[%s="%d"]`, fontProbeAttr, i)).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		usage, err := css.GetPlatformFontsForNode(nodeID).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		r := resolvedFont{Family: family, PlatformFonts: make([]platformFont, 0, len(usage))}
+		for _, u := range usage {
+			r.PlatformFonts = append(r.PlatformFonts, platformFont{
+				Name:           u.FamilyName,
+				PostScriptName: u.PostScriptName,
+				Custom:         u.IsCustomFont,
+				Glyphs:         u.GlyphCount,
+			})
+		}
+		r.Fallback = fellBack(family, r.PlatformFonts)
+		resolved = append(resolved, r)
+	}
+	return resolved, nil
+}
+
+// fellBack reports whether text styled with the font-family list was drawn without its first
+// family. Generic families never fall back; a web font in use counts as the requested one.
+func fellBack(family string, used []platformFont) bool {
+	first := firstFamily(family)
+	if first == "" || genericFamilies[strings.ToLower(first)] || len(used) == 0 {
+		return false
+	}
+	for _, u := range used {
+		if u.Custom || strings.EqualFold(u.Name, first) {
+			return false
+		}
+	}
+	return true
+}
+
+// firstFamily returns the first name of a CSS font-family list, unquoted.
+func firstFamily(list string) string {
+	first, _, _ := strings.Cut(list, ",")
+	return strings.Trim(strings.TrimSpace(first), `"'`)
+}
+
+// checkFontFallbacks logs the font families of the document that fell back and adds them to the
+// render diagnostics. It never fails the render.
+func checkFontFallbacks() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		resolved, err := resolveFonts(ctx)
+		if err != nil {
+			return nil
+		}
+		var fellBack []string
+		for _, r := range resolved {
+			if !r.Fallback {
+				continue
+			}
+			fellBack = append(fellBack, r.Family)
+			used := make([]string, 0, len(r.PlatformFonts))
+			for _, p := range r.PlatformFonts {
+				used = append(used, p.Name)
+			}
+			diagnosticsFrom(ctx).addFontFallback(fontFallback{Family: clip(r.Family), Used: used})
+		}
+		if len(fellBack) > 0 {
+			logging.Warn("Font families fell back", "families", fellBack, "trace_id", tracing.TraceID(ctx))
+		}
+		return nil
+	})
+}
+
+// HandleFonts lists the fonts of render.fonts_dir and how Chrome resolves them, together with
+// the generic families and any families given in the "family" query parameter.
+func (svc *PDFService) HandleFonts(c *fiber.Ctx) error {
+	resp := fiber.Map{"fonts_dir": "", "faces": []fonts.Face{}}
+	families := []string{"serif", "sans-serif", "monospace"}
+	if svc.Fonts != nil {
+		resp["fonts_dir"] = svc.Fonts.Dir()
+		resp["faces"] = svc.Fonts.Faces()
+		families = append(families, svc.Fonts.Families()...)
+	}
+	for _, f := range splitList(c.Query("family")) {
+		if len(families) < maxCheckedFontFamilies {
+			families = append(families, f)
+		}
+	}
+
+	renderer, err := svc.getRenderer()
+	if err != nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Renderer unavailable")
+	}
+	runner, ok := renderer.(tabRunner)
+	if !ok {
+		return c.JSON(resp) // e.g. a fake renderer: nothing to ask Chrome
+	}
+
+	var resolved []resolvedFont
+	opts := svc.renderOptions()
+	err = runner.runInTab(c.UserContext(), func(ctx context.Context) error {
+		actions := interceptRequests(ctx, domain.ResourcePolicy{}, opts)
+		return chromedp.Run(ctx, append(actions,
+			chromedp.Navigate("about:blank"),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				tree, err := page.GetFrameTree().Do(ctx)
+				if err != nil {
+					return err
+				}
+				return page.SetDocumentContent(tree.Frame.ID, fontSpecimen(families)).Do(ctx)
+			}),
+			injectFonts(opts.fonts),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				_, err := waitForCondition(ctx, "fonts", `document.fonts.status === "loaded"`, time.Now().Add(5*time.Second))
+				return err
+			}),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				var err error
+				resolved, err = resolveFonts(ctx)
+				return err
+			}),
+		)...)
+	})
+	if err != nil {
+		logging.Warn("Font resolution failed", "error", err)
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Font resolution failed")
+	}
+	resp["resolved"] = resolved
+	return c.JSON(resp)
+}
+
+// fontSpecimen is a page with one line of text per font family.
+func fontSpecimen(families []string) string {
+	var b strings.Builder
+	b.WriteString("<!doctype html><html><body>")
+	for _, f := range families {
+		family := f
+		if !genericFamilies[strings.ToLower(f)] {
+			family = `"` + strings.NewReplacer(`"`, ``, `\`, ``).Replace(f) + `"`
+		}
+		fmt.Fprintf(&b, `<p style="font-family: %s">The quick brown fox jumps over the lazy dog 0123456789</p>`, html.EscapeString(family))
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
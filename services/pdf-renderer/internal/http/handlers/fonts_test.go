@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"pdf-renderer/internal/infra/fake"
+	"pdf-renderer/internal/infra/fonts"
+)
+
+func TestFellBack(t *testing.T) {
+	tests := []struct {
+		name   string
+		family string
+		used   []platformFont
+		want   bool
+	}{
+		{"installed font", `Arial, sans-serif`, []platformFont{{Name: "Arial"}}, false},
+		{"substituted font", `"Brand Sans", Arial, sans-serif`, []platformFont{{Name: "Liberation Sans"}}, true},
+		{"web font", `"Brand Sans", sans-serif`, []platformFont{{Name: "BrandSans", Custom: true}}, false},
+		{"case-insensitive", `'dejavu serif'`, []platformFont{{Name: "DejaVu Serif"}}, false},
+		{"generic family", `sans-serif`, []platformFont{{Name: "DejaVu Sans"}}, false},
+		{"no glyphs", `"Brand Sans"`, nil, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fellBack(tc.family, tc.used); got != tc.want {
+				t.Fatalf("fellBack(%q) = %v, want %v", tc.family, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFontSpecimen(t *testing.T) {
+	page := fontSpecimen([]string{"serif", `Brand "Sans"`, "<script>"})
+	for _, want := range []string{
+		`font-family: serif`,
+		`font-family: &#34;Brand Sans&#34;`,
+		`font-family: &#34;&lt;script&gt;&#34;`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected specimen to contain %s, got %s", want, page)
+		}
+	}
+}
+
+func TestHandleFonts_ListsFaces(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Go-Regular.ttf"), goregular.TTF, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := fonts.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	svc, app := newFakeService(t, fake.NewRenderer())
+	svc.Fonts = lib
+	app.Get("/ops/fonts", svc.HandleFonts)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/ops/fonts", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var body struct {
+		FontsDir string       `json:"fonts_dir"`
+		Faces    []fonts.Face `json:"faces"`
+		Resolved []any        `json:"resolved"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.FontsDir != dir || len(body.Faces) != 1 || body.Faces[0].Family != "Go" {
+		t.Fatalf("unexpected fonts response %+v", body)
+	}
+	if body.Resolved != nil {
+		t.Fatalf("expected no Chrome resolution with a fake renderer, got %v", body.Resolved)
+	}
+}
+
+func TestRenderDiagnostics_FontFallbacks(t *testing.T) {
+	_, d := withRenderDiagnostics(t.Context())
+	d.addFontFallback(fontFallback{Family: `"Brand Sans", sans-serif`, Used: []string{"DejaVu Sans"}})
+
+	r := d.snapshot()
+	if len(r.FontFallbacks) != 1 || !strings.HasSuffix(r.summary(), ", font_fallbacks=1") {
+		t.Fatalf("unexpected report %+v / %s", r.FontFallbacks, r.summary())
+	}
+}
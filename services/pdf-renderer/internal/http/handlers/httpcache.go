@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultCacheControl is used when cache.http_cache_control is empty: clients may store the PDF
+// but must revalidate it (cheaply, via If-None-Match) before reuse.
+const defaultCacheControl = "private, no-cache"
+
+// pdfETag returns the entity tag of the PDF for a request, derived from its cache key. Being known
+// before rendering, it lets a matching If-None-Match be answered without a render. It is weak, as
+// two renders of the same request are equivalent but not necessarily byte-identical (timestamps,
+// Chrome updates).
+func pdfETag(cacheKey string) string {
+	sum := sha256.Sum256([]byte(cacheKey))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+// If-None-Match uses the weak comparison function (RFC 9110, section 13.1.2).
+func etagMatches(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setValidators sets the HTTP caching headers of a PDF response and reports whether the request
+// is a conditional GET/HEAD whose If-None-Match matches etag, to be answered with 304 Not Modified.
+func (svc *PDFService) setValidators(c *fiber.Ctx, etag string) bool {
+	cacheControl := svc.Config.Cache.HTTPCacheControl
+	if cacheControl == "" {
+		cacheControl = defaultCacheControl
+	}
+	c.Set("ETag", etag)
+	c.Set("Cache-Control", cacheControl)
+
+	method := c.Method()
+	return (method == fiber.MethodGet || method == fiber.MethodHead) && etagMatches(c.Get("If-None-Match"), etag)
+}
+
+// sendPDF writes a PDF response including HTTP caching validators.
+// Conditional GET/HEAD requests whose If-None-Match matches receive 304 Not Modified.
+func (svc *PDFService) sendPDF(c *fiber.Ctx, params *PDFRequestParams, etag string, pdfBuf []byte, cacheHit bool) error {
+	xCache := "MISS"
+	if cacheHit {
+		xCache = "HIT"
+	}
+	c.Set("X-Cache", xCache)
+	if svc.setValidators(c, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", "attachment; filename="+params.Filename)
+	return c.Send(pdfBuf)
+}
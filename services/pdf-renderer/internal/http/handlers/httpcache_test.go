@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+
+	"pdf-renderer/internal/infra/fake"
+)
+
+func TestEtagMatches(t *testing.T) {
+	etag := pdfETag("pdfcache:abc")
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"empty", "", false},
+		{"wildcard", "*", true},
+		{"exact", etag, true},
+		{"strong form", strings.TrimPrefix(etag, "W/"), true},
+		{"list", `"other", ` + etag, true},
+		{"mismatch", `"other"`, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := etagMatches(tc.header, etag); got != tc.want {
+				t.Fatalf("etagMatches(%q) = %v, want %v", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPDFETag_WeakAndRequestDerived(t *testing.T) {
+	a := pdfETag("pdfcache:a")
+	if !strings.HasPrefix(a, `W/"`) || !strings.HasSuffix(a, `"`) {
+		t.Fatalf("expected quoted weak etag, got %s", a)
+	}
+	if a == pdfETag("pdfcache:b") {
+		t.Fatalf("expected different requests to yield different etags")
+	}
+	if a != pdfETag("pdfcache:a") {
+		t.Fatalf("expected etag to be stable")
+	}
+}
+
+func TestProcessPDFGeneration_CacheHitHeadersAndNotModified(t *testing.T) {
+	mrs, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer mrs.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mrs.Addr()})
+	cfg := testPDFCfg()
+	cfg.Cache.HTTPCacheControl = "public, max-age=60"
+	svc := NewPDFService(cfg, rdb)
+
+	params := &PDFRequestParams{HTML: "<html>hello world</html>", Format: "A4", Orientation: "portrait", Margin: 0.4, Filename: "x.pdf"}
+	cached := []byte("cached-pdf")
+	_ = mrs.Set(computePDFCacheKey(params), string(cached))
+	mrs.SetTTL(computePDFCacheKey(params), time.Minute)
+
+	app := fiber.New()
+	handler := func(c *fiber.Ctx) error { return svc.processPDFGeneration(c, params) }
+	app.Get("/pdf", handler)
+	app.Post("/pdf", handler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/pdf", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	if want := pdfETag(computePDFCacheKey(params)); etag != want {
+		t.Fatalf("expected request etag %s, got %s", want, etag)
+	}
+	if got := resp.Header.Get("X-Cache"); got != "HIT" {
+		t.Fatalf("expected X-Cache HIT, got %q", got)
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "public, max-age=60" {
+		t.Fatalf("unexpected Cache-Control %q", got)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/pdf" {
+		t.Fatalf("unexpected Content-Type %q", got)
+	}
+
+	conditional := httptest.NewRequest("GET", "/pdf", nil)
+	conditional.Header.Set("If-None-Match", etag)
+	resp, err = app.Test(conditional)
+	if err != nil {
+		t.Fatalf("conditional request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotModified {
+		t.Fatalf("expected 304, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("ETag") != etag {
+		t.Fatalf("expected 304 to repeat the etag")
+	}
+
+	// If-None-Match is only honoured for safe methods.
+	post := httptest.NewRequest("POST", "/pdf", nil)
+	post.Header.Set("If-None-Match", etag)
+	resp, err = app.Test(post)
+	if err != nil {
+		t.Fatalf("post request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200 for POST, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleURLConversion_NotModifiedSkipsRender(t *testing.T) {
+	r := fake.NewRenderer()
+	_, app := newFakeService(t, r)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/pdf?url=https://example.com", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != fiber.StatusOK || etag == "" || r.Renders() != 1 {
+		t.Fatalf("expected a rendered PDF with an etag, got %d %q after %d renders", resp.StatusCode, etag, r.Renders())
+	}
+
+	conditional := httptest.NewRequest("GET", "/pdf?url=https://example.com", nil)
+	conditional.Header.Set("If-None-Match", etag)
+	resp, err = app.Test(conditional)
+	if err != nil {
+		t.Fatalf("conditional request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotModified || resp.Header.Get("ETag") != etag {
+		t.Fatalf("expected 304 with etag %s, got %d %q", etag, resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	if r.Renders() != 1 {
+		t.Fatalf("expected the revalidation to be answered without rendering, got %d renders", r.Renders())
+	}
+
+	other := httptest.NewRequest("GET", "/pdf?url=https://example.com&format=LETTER", nil)
+	other.Header.Set("If-None-Match", etag)
+	resp, err = app.Test(other)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK || r.Renders() != 2 {
+		t.Fatalf("expected a different request to be rendered, got %d after %d renders", resp.StatusCode, r.Renders())
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/config"
+)
+
+const (
+	defaultMaxInjectCSSBytes = 64 << 10
+	defaultMaxInjectJSBytes  = 16 << 10
+)
+
+// injectedScriptError is returned when the js parameter throws or its promise rejects.
+type injectedScriptError struct {
+	message string
+}
+
+func (e *injectedScriptError) Error() string {
+	return "injected script failed: " + e.message
+}
+
+// extractInjection reads the css and js parameters. JavaScript may only be injected by callers
+// holding one of the render.inject_js_scopes (forwarded by the gateway in X-Auth-Scopes).
+func extractInjection(c *fiber.Ctx, cfg config.Config) (styles, script string, err error) {
+	maxCSS, maxJS := cfg.Render.MaxInjectCSSBytes, cfg.Render.MaxInjectJSBytes
+	if maxCSS <= 0 {
+		maxCSS = defaultMaxInjectCSSBytes
+	}
+	if maxJS <= 0 {
+		maxJS = defaultMaxInjectJSBytes
+	}
+
+	styles = c.FormValue("css")
+	if len(styles) > maxCSS {
+		return "", "", fiber.NewError(fiber.StatusRequestEntityTooLarge, "css exceeds "+strconv.Itoa(maxCSS)+" bytes")
+	}
+	script = c.FormValue("js")
+	if strings.TrimSpace(script) == "" {
+		return styles, "", nil
+	}
+	if len(script) > maxJS {
+		return "", "", fiber.NewError(fiber.StatusRequestEntityTooLarge, "js exceeds "+strconv.Itoa(maxJS)+" bytes")
+	}
+	if !scopeAllowed(c, cfg.Render.InjectJSScopes) {
+		return "", "", fiber.NewError(fiber.StatusForbidden, "JavaScript injection is not allowed for this API key")
+	}
+	return styles, script, nil
+}
+
+// scopeAllowed reports whether the caller holds one of the allowed scopes, as forwarded by the
+// gateway in X-Auth-Scopes. "*" allows every caller, including public ones.
+func scopeAllowed(c *fiber.Ctx, allowed []string) bool {
+	if slices.Contains(allowed, "*") {
+		return true
+	}
+	if c.Get("X-Auth-Mode") != "token" {
+		return false
+	}
+	for _, scope := range splitList(c.Get("X-Auth-Scopes")) {
+		if slices.Contains(allowed, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// injectionCacheKey returns the part of the cache key that depends on the injected CSS and
+// JavaScript; it is empty without either so existing cache entries stay valid.
+func injectionCacheKey(styles, script string) string {
+	var b strings.Builder
+	if styles != "" {
+		fmt.Fprintf(&b, "|css=%x", sha256.Sum256([]byte(styles)))
+	}
+	if script != "" {
+		fmt.Fprintf(&b, "|js=%x", sha256.Sum256([]byte(script)))
+	}
+	return b.String()
+}
+
+// injectContent adds the style sheet to the current document and runs the script, awaiting it.
+// A script that throws fails the render with an injectedScriptError.
+func injectContent(styles, script string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if styles != "" {
+			tree, err := page.GetFrameTree().Do(ctx)
+			if err != nil {
+				return err
+			}
+			id, err := css.CreateStyleSheet(tree.Frame.ID).Do(ctx)
+			if err != nil {
+				return err
+			}
+			if _, err := css.SetStyleSheetText(id, styles).Do(ctx); err != nil {
+				return err
+			}
+		}
+		if script == "" {
+			return nil
+		}
+		_, exception, err := runtime.Evaluate("(async () => {\n" + script + "\n})()").
+			WithAwaitPromise(true).
+			Do(ctx)
+		if err != nil {
+			return err
+		}
+		if exception != nil {
+			msg := exception.Text
+			if exception.Exception != nil && exception.Exception.Description != "" {
+				msg = exception.Exception.Description
+			}
+			return &injectedScriptError{message: clip(msg)}
+		}
+		return nil
+	})
+}
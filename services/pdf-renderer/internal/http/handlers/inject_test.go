@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/infra/fake"
+)
+
+func TestExtractInjection(t *testing.T) {
+	cfg := testPDFCfg()
+	cfg.Render.MaxInjectCSSBytes = 20
+	cfg.Render.MaxInjectJSBytes = 20
+	cfg.Render.InjectJSScopes = []string{"inject"}
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		styles, script, err := extractInjection(c, cfg)
+		if err != nil {
+			return err
+		}
+		return c.SendString(styles + "|" + script)
+	})
+
+	tests := []struct {
+		name    string
+		css, js string
+		headers map[string]string
+		status  int
+		want    string
+	}{
+		{"nothing", "", "", nil, fiber.StatusOK, "|"},
+		{"css for everyone", ".x{display:none}", "", nil, fiber.StatusOK, ".x{display:none}|"},
+		{"css too large", strings.Repeat("a", 21), "", nil, fiber.StatusRequestEntityTooLarge, ""},
+		{"js without token", "", "f()", nil, fiber.StatusForbidden, ""},
+		{"js without scope", "", "f()", map[string]string{"X-Auth-Mode": "token", "X-Auth-Scopes": "api"}, fiber.StatusForbidden, ""},
+		{"js with scope", "", "f()", map[string]string{"X-Auth-Mode": "token", "X-Auth-Scopes": "api,inject"}, fiber.StatusOK, "|f()"},
+		{"spoofed scopes", "", "f()", map[string]string{"X-Auth-Mode": "public", "X-Auth-Scopes": "inject"}, fiber.StatusForbidden, ""},
+		{"js too large", "", strings.Repeat("a", 21), map[string]string{"X-Auth-Mode": "token", "X-Auth-Scopes": "inject"}, fiber.StatusRequestEntityTooLarge, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := url.Values{}
+			if tc.css != "" {
+				q.Set("css", tc.css)
+			}
+			if tc.js != "" {
+				q.Set("js", tc.js)
+			}
+			req := httptest.NewRequest("GET", "/?"+q.Encode(), nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, resp.StatusCode)
+			}
+			if tc.status != fiber.StatusOK {
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if got := string(body); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestScopeAllowed_Wildcard(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		if !scopeAllowed(c, []string{"*"}) || scopeAllowed(c, nil) {
+			return fiber.ErrForbidden
+		}
+		return nil
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil || resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected '*' to allow public callers and no scopes to forbid them, got %v %v", resp.StatusCode, err)
+	}
+}
+
+func TestComputePDFCacheKey_Injection(t *testing.T) {
+	base := PDFRequestParams{HTML: "<html>x</html>", Format: "A4", Orientation: "portrait", Margin: 0.4}
+	withCSS, withJS := base, base
+	withCSS.CSS = "body{color:red}"
+	withJS.Script = "body{color:red}"
+
+	keys := map[string]bool{
+		computePDFCacheKey(&base):    true,
+		computePDFCacheKey(&withCSS): true,
+		computePDFCacheKey(&withJS):  true,
+	}
+	if len(keys) != 3 {
+		t.Fatalf("expected injected CSS and JS to produce distinct cache keys")
+	}
+	if injectionCacheKey("", "") != "" {
+		t.Fatalf("expected no injection to keep existing cache keys")
+	}
+}
+
+func TestRenderError_InjectedScript(t *testing.T) {
+	svc, _ := newFakeService(t, fake.NewRenderer())
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		return svc.renderError(c, &PDFRequestParams{}, errors.Join(errors.New("render"), &injectedScriptError{message: "ReferenceError: f is not defined"}))
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", resp.StatusCode)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
 	neturl "net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -18,10 +20,17 @@ import (
 	"github.com/chromedp/chromedp"
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
+	"github.com/rs/xid"
+	"go.opentelemetry.io/otel/attribute"
 
 	"pdf-renderer/internal/config"
+	"pdf-renderer/internal/domain"
+	"pdf-renderer/internal/infra/assetcache"
 	"pdf-renderer/internal/infra/chrome"
+	"pdf-renderer/internal/infra/fonts"
 	"pdf-renderer/internal/infra/logging"
+	"pdf-renderer/internal/infra/metrics"
+	"pdf-renderer/internal/infra/tracing"
 )
 
 // PDFRequestParams holds validated input parameters.
@@ -33,6 +42,13 @@ type PDFRequestParams struct {
 	Margin      float64
 	Filename    string
 	Paper       config.PaperSize
+	Tenant      string        // caller identity used for fair scheduling
+	Priority    string        // domain.PriorityInteractive or domain.PriorityBatch
+	Timeout     time.Duration // render timeout requested by the client; 0 uses pdf.timeout_secs
+	Debug       string        // "", debugWarnings or debugReport
+	Policy      domain.ResourcePolicy
+	CSS         string // injected style sheet
+	Script      string // injected JavaScript
 }
 
 // PDFService bundles configuration and dependencies for PDF rendering.
@@ -40,9 +56,20 @@ type PDFService struct {
 	Config *config.Config
 	Redis  *redis.Client
 
+	// Renderer overrides the Chrome-backed renderer (e.g. with a fake in tests).
+	// When nil, the Chrome pool is used, or a per-request Chrome when pooling is disabled.
+	Renderer domain.Renderer
+
+	// Assets serves page sub-resources from the local asset cache; nil when asset_cache is disabled.
+	Assets *assetcache.Cache
+	// Fonts are the custom fonts of render.fonts_dir made available to every render; nil without one.
+	Fonts *fonts.Library
+
 	poolMu  sync.Mutex
 	pool    *chrome.Pool
 	poolErr error
+
+	renders renderGroup // deduplicates identical in-flight renders
 }
 
 // HandlePDFConversion returns a Fiber handler for PDF conversion requests.
@@ -57,12 +84,95 @@ func HandlePDFURL(cfg config.Config, rdb *redis.Client) fiber.Handler {
 	return svc.HandleURLConversion
 }
 
-// NewPDFService creates a new PDFService instance.
+// NewPDFService creates a new PDFService instance. It panics if asset_cache.offline is set and
+// the asset cache cannot be opened: renders must not fall back to the network.
 func NewPDFService(cfg config.Config, rdb *redis.Client) *PDFService {
+	assets, err := newAssetCache(cfg)
+	if err != nil {
+		panic("Offline asset cache unavailable: " + err.Error())
+	}
 	return &PDFService{
 		Config: &cfg, // convert value to pointer
 		Redis:  rdb,
+		Assets: assets,
+		Fonts:  loadFonts(cfg),
+	}
+}
+
+// loadFonts loads render.fonts_dir. A directory that cannot be loaded is logged and skipped.
+func loadFonts(cfg config.Config) *fonts.Library {
+	if cfg.Render.FontsDir == "" {
+		return nil
+	}
+	lib, err := fonts.Load(cfg.Render.FontsDir)
+	if err != nil {
+		logging.Error("Custom fonts unavailable", "dir", cfg.Render.FontsDir, "error", err)
+		return nil
+	}
+	logging.Info("Custom fonts loaded", "dir", cfg.Render.FontsDir, "faces", len(lib.Faces()), "families", lib.Families())
+	return lib
+}
+
+// renderOptions collects what renders use besides Chrome and the request.
+func (svc *PDFService) renderOptions() renderOptions {
+	return renderOptions{
+		assets:     svc.Assets,
+		fonts:      svc.Fonts,
+		checkFonts: svc.Config.Render.ReportFontFallbacks,
+	}
+}
+
+// newAssetCache opens the asset cache configured in asset_cache. A cache that cannot be opened
+// is logged and skipped, so pages load their sub-resources directly, unless it runs in offline
+// mode: then the error is returned.
+func newAssetCache(cfg config.Config) (*assetcache.Cache, error) {
+	ac := cfg.AssetCache
+	if !ac.Enabled {
+		return nil, nil
+	}
+	opts := assetcache.Options{
+		Dir:          ac.Dir,
+		MaxBytes:     int64(ac.MaxSizeMB) << 20,
+		SeedDir:      ac.SeedDir,
+		Offline:      ac.Offline,
+		DefaultTTL:   ac.DefaultTTL,
+		FetchTimeout: time.Duration(ac.FetchTimeoutSecs) * time.Second,
+	}
+	if opts.Dir == "" {
+		opts.Dir = filepath.Join(os.TempDir(), "html2pdf-assets")
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 256 << 20
+	}
+	if opts.DefaultTTL <= 0 {
+		opts.DefaultTTL = 24 * time.Hour
+	}
+	if opts.FetchTimeout <= 0 {
+		opts.FetchTimeout = 10 * time.Second
+	}
+
+	var err error
+	if !opts.Offline {
+		// Assets are downloaded through the same proxy as the pages Chromium loads.
+		var transport *http.Transport
+		if transport, err = chrome.HTTPTransport(cfg); err == nil {
+			opts.Client = &http.Client{Timeout: opts.FetchTimeout, Transport: transport}
+		}
+	}
+	var assets *assetcache.Cache
+	if err == nil {
+		assets, err = assetcache.New(opts)
+	}
+	if err != nil {
+		if opts.Offline {
+			return nil, err
+		}
+		logging.Error("Asset cache unavailable; sub-resources are loaded directly", "dir", opts.Dir, "error", err)
+		return nil, nil
 	}
+	entries, size, seeded := assets.Stats()
+	logging.Info("Asset cache ready", "dir", opts.Dir, "entries", entries, "bytes", size, "seeded", seeded, "offline", opts.Offline)
+	return assets, nil
 }
 
 func (svc *PDFService) getChromePool() (*chrome.Pool, error) {
@@ -86,106 +196,385 @@ func (svc *PDFService) getChromePool() (*chrome.Pool, error) {
 
 // HandleConversion generates a new PDF or serves a cached copy.
 func (svc *PDFService) HandleConversion(c *fiber.Ctx) error {
-	params, err := validateAndExtractPDFParams(c, *svc.Config)
-	if err != nil {
-		return err
-	}
-	return svc.processPDFGeneration(c, params)
+	return svc.handle(c, validateAndExtractPDFParams)
 }
 
 // HandleURLConversion fetches HTML from a URL and generates a PDF.
 func (svc *PDFService) HandleURLConversion(c *fiber.Ctx) error {
-	params, err := validateAndExtractURLParams(c, *svc.Config)
+	return svc.handle(c, validateAndExtractURLParams)
+}
+
+// handle validates the request and produces the PDF, timing every phase. The timings are
+// returned in a Server-Timing header, also on errors.
+func (svc *PDFService) handle(c *fiber.Ctx, validate func(*fiber.Ctx, config.Config) (*PDFRequestParams, error)) error {
+	start := time.Now()
+	ctx, timings := withRenderTimings(c.UserContext())
+	c.SetUserContext(ctx)
+	defer func() {
+		timings.since("total", start)
+		c.Set("Server-Timing", timings.serverTiming())
+	}()
+
+	_, span := tracing.Start(ctx, "pdf.validate")
+	params, err := validate(c, *svc.Config)
+	tracing.End(span, err)
+	timings.since("validate", start)
 	if err != nil {
 		return err
 	}
+
+	// Abort the render (queued or running) when the client goes away.
+	ctx, stop := watchDisconnect(c)
+	defer stop()
+	c.SetUserContext(ctx)
 	return svc.processPDFGeneration(c, params)
 }
 
 // processPDFGeneration handles caching and PDF rendering.
 func (svc *PDFService) processPDFGeneration(c *fiber.Ctx, params *PDFRequestParams) error {
+	if params.Debug != "" {
+		return svc.renderDebug(c, params)
+	}
+
+	start := time.Now()
+	ctx := c.UserContext()
+	timings := timingsFrom(ctx)
 	cacheKey := computePDFCacheKey(params)
+	etag := pdfETag(cacheKey)
+
+	// The ETag depends only on the request, so revalidations are answered without a cache lookup or render.
+	if svc.setValidators(c, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
 
 	// Try to serve from Redis cache
-	if svc.Redis != nil && svc.Config.Cache.PDFCacheEnabled {
-		if cached, err := getCachedPDF(c, svc.Redis, cacheKey, params.Filename); err == nil && cached != nil {
-			return c.Send(cached)
+	if svc.cacheEnabled() {
+		_, span := tracing.Start(ctx, "cache.get")
+		cached, err := getCachedPDF(c, svc.Redis, cacheKey)
+		span.SetAttributes(attribute.Bool("cache.hit", err == nil && cached != nil))
+		tracing.End(span, err)
+		timings.since("cache_get", start)
+		if err == nil && cached != nil {
+			metrics.CacheRequests.WithLabelValues("hit").Inc()
+			svc.observeRender(params, "hit", start, cached)
+			return svc.sendPDF(c, params, etag, cached, true)
 		}
+		metrics.CacheRequests.WithLabelValues("miss").Inc()
+	}
+
+	// Generate PDF. Identical concurrent requests share a single render, which keeps running
+	// while any of them still waits for it.
+	detached := params.clone()
+	renderStart := time.Now()
+	renderCtx, span := tracing.Start(ctx, "pdf.render")
+	pdfBuf, shared, err := svc.renders.Do(renderCtx, cacheKey, func(ctx context.Context) ([]byte, error) {
+		return svc.renderAndCache(ctx, cacheKey, detached)
+	})
+	span.SetAttributes(attribute.Bool("render.coalesced", shared))
+	tracing.End(span, err)
+	timings.since("render", renderStart)
+	if err != nil {
+		return svc.renderError(c, params, err)
+	}
+
+	requestID := c.Get("X-Request-ID")
+	fields := []any{"filename", params.Filename, "request_id", requestID, "trace_id", tracing.TraceID(ctx), "coalesced", shared}
+	logging.Info("PDF generated", append(fields, timings.logFields()...)...)
+
+	outcome := "miss"
+	if shared {
+		outcome = "shared"
+	}
+	svc.observeRender(params, outcome, start, pdfBuf)
+
+	return svc.sendPDF(c, params, etag, pdfBuf, false)
+}
+
+// renderError logs a failed render and maps it to the HTTP error returned to the client.
+// Renders abandoned by a disconnected client are counted as cancellations, not failures.
+func (svc *PDFService) renderError(c *fiber.Ctx, params *PDFRequestParams, err error) *fiber.Error {
+	if errors.Is(err, context.Canceled) && c.UserContext().Err() != nil {
+		metrics.RenderCancellations.Inc()
+		logging.Info("PDF render cancelled by client", "request_id", c.Get("X-Request-ID"), "tenant", params.Tenant, "trace_id", tracing.TraceID(c.UserContext()))
+		return fiber.NewError(statusClientClosedRequest, "Client closed request")
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		metrics.RenderFailures.WithLabelValues("rejected").Inc()
+		return fiberErr
+	}
+	var scriptErr *injectedScriptError
+	if errors.As(err, &scriptErr) {
+		metrics.RenderFailures.WithLabelValues("script").Inc()
+		logging.Warn("Injected script failed", "error", scriptErr.message)
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "Injected script failed: "+scriptErr.message)
+	}
+	if errors.Is(err, chrome.ErrQueueFull) || errors.Is(err, chrome.ErrQueueTimeout) {
+		metrics.RenderFailures.WithLabelValues("busy").Inc()
+		logging.Warn("PDF render not scheduled", "tenant", params.Tenant, "priority", params.Priority, "error", err.Error())
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Renderer busy, please retry")
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		metrics.RenderFailures.WithLabelValues("timeout").Inc()
+		// Log the underlying error so we can distinguish between:
+		// - Chrome pool init warmup timeout
+		// - Pool acquire timeout (no free tab)
+		// - Actual render timeout
+		logging.Error("PDF generation timeout", "timeout_secs", svc.renderTimeout(params).Seconds(), "error", err.Error())
+		return fiber.NewError(fiber.StatusRequestTimeout, "PDF rendering took too long")
+	}
+	if chrome.IsSessionInterrupted(err) {
+		metrics.RenderFailures.WithLabelValues("session").Inc()
+		logging.Error("Chrome session interrupted", "error", err.Error())
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Chrome session interrupted")
+	}
+	metrics.RenderFailures.WithLabelValues("error").Inc()
+	logging.Error("PDF generation failed", "error", err.Error())
+	return fiber.NewError(fiber.StatusInternalServerError, "PDF generation failed: "+err.Error())
+}
+
+// renderDebug renders with diagnostics collection. It bypasses the PDF cache and coalescing,
+// since the diagnostics belong to the tab that rendered this request. With debug=report the
+// response is the JSON diagnostics report (including the error, if the render failed); otherwise
+// the PDF is sent with a summary in X-Render-Warnings.
+func (svc *PDFService) renderDebug(c *fiber.Ctx, params *PDFRequestParams) error {
+	start := time.Now()
+	ctx, diagnostics := withRenderDiagnostics(c.UserContext())
+
+	ctx, span := tracing.Start(ctx, "pdf.render", attribute.Bool("render.debug", true))
+	pdfBuf, err := svc.renderPDF(ctx, params)
+	if err == nil && len(pdfBuf) > svc.Config.Limits.MaxPDFBytes {
+		err = fiber.NewError(fiber.StatusRequestEntityTooLarge, "PDF exceeds allowed size")
 	}
+	tracing.End(span, err)
+	timingsFrom(ctx).since("render", start)
 
-	// Generate PDF
-	pdfBuf, err := svc.renderPDF(params)
+	report := diagnostics.snapshot()
+	var renderErr *fiber.Error
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			// Log the underlying error so we can distinguish between:
-			// - Chrome pool init warmup timeout
-			// - Pool acquire timeout (no free tab)
-			// - Actual render timeout
-			logging.Error("PDF generation timeout", "timeout_secs", svc.Config.PDF.TimeoutSecs, "error", err.Error())
-			return fiber.NewError(fiber.StatusRequestTimeout, "PDF rendering took too long")
+		renderErr = svc.renderError(c, params, err)
+	}
+
+	if params.Debug == debugReport {
+		c.Set("Cache-Control", "no-store")
+		body := fiber.Map{"diagnostics": report}
+		if renderErr != nil {
+			body["error"] = fiber.Map{"code": renderErr.Code, "message": renderErr.Message}
+			return c.Status(renderErr.Code).JSON(body)
 		}
-		if chrome.IsSessionInterrupted(err) {
-			logging.Error("Chrome session interrupted", "error", err.Error())
-			return fiber.NewError(fiber.StatusServiceUnavailable, "Chrome session interrupted")
+		body["pdf_bytes"] = len(pdfBuf)
+		return c.JSON(body)
+	}
+
+	c.Set("X-Render-Warnings", report.summary())
+	if renderErr != nil {
+		return renderErr
+	}
+	fields := []any{"filename", params.Filename, "request_id", c.Get("X-Request-ID"), "trace_id", tracing.TraceID(ctx), "debug", report.summary()}
+	logging.Info("PDF generated", append(fields, timingsFrom(ctx).logFields()...)...)
+	return svc.sendPDF(c, params, pdfETag(computePDFCacheKey(params)), pdfBuf, false)
+}
+
+// observeRender records render latency and PDF size metrics.
+func (svc *PDFService) observeRender(params *PDFRequestParams, cache string, start time.Time, pdfBuf []byte) {
+	input := "html"
+	if params.URL != "" {
+		input = "url"
+	}
+	paper := params.Format
+	if paper == "" {
+		paper = strings.ToUpper(svc.Config.PDF.DefaultPaper)
+	}
+	metrics.RenderDuration.WithLabelValues(input, paper, cache).Observe(time.Since(start).Seconds())
+	metrics.PDFSize.Observe(float64(len(pdfBuf)))
+}
+
+// renderAndCache renders the PDF, enforces the size limit and stores the result in Redis.
+// With render_lock_enabled, replicas coordinate through a Redis lock so that only one of them
+// renders a given document while the others wait for the cached result. It may outlive the
+// request that started it (see renderGroup), so it must not touch the Fiber context.
+func (svc *PDFService) renderAndCache(ctx context.Context, cacheKey string, params *PDFRequestParams) ([]byte, error) {
+	if svc.cacheEnabled() && svc.Config.Cache.RenderLockEnabled {
+		lockKey := renderLockKey(cacheKey)
+		owner := xid.New().String()
+
+		acquired, err := acquireRenderLock(ctx, svc.Redis, lockKey, owner, svc.renderLockTTL())
+		switch {
+		case err != nil:
+			logging.Warn("Render lock unavailable; rendering locally", "key", cacheKey, "error", err)
+		case acquired:
+			defer func() {
+				if err := releaseRenderLock(context.Background(), svc.Redis, lockKey, owner); err != nil {
+					logging.Warn("Render lock release failed", "key", cacheKey, "error", err)
+				}
+			}()
+		default:
+			waitStart := time.Now()
+			waitCtx, cancel := context.WithTimeout(ctx, svc.renderWaitTimeout())
+			_, span := tracing.Start(ctx, "render_lock.wait")
+			cached, err := waitForCachedPDF(waitCtx, svc.Redis, cacheKey, lockKey, 100*time.Millisecond)
+			tracing.End(span, err)
+			cancel()
+			timingsFrom(ctx).since("lock_wait", waitStart)
+			if err == nil && cached != nil {
+				logging.Info("PDF shared from another replica", "key", cacheKey)
+				return cached, nil
+			}
+			// The leader failed or took too long; fall back to rendering here.
+			logging.Warn("Render lock wait ended without result; rendering locally", "key", cacheKey, "error", err)
 		}
-		logging.Error("PDF generation failed", "error", err.Error())
-		return fiber.NewError(fiber.StatusInternalServerError, "PDF generation failed: "+err.Error())
+	}
+
+	pdfBuf, err := svc.renderPDF(ctx, params)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(pdfBuf) > svc.Config.Limits.MaxPDFBytes {
-		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "PDF exceeds allowed size")
+		return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge, "PDF exceeds allowed size")
 	}
 
 	// Cache PDF
-	if svc.Redis != nil && svc.Config.Cache.PDFCacheEnabled {
-		setCachedPDF(c, svc.Redis, cacheKey, pdfBuf, svc.Config.Cache.PDFCacheTTL)
+	if svc.cacheEnabled() {
+		setStart := time.Now()
+		_, span := tracing.Start(ctx, "cache.set")
+		setCachedPDF(ctx, svc.Redis, cacheKey, pdfBuf, svc.Config.Cache.PDFCacheTTL)
+		span.End()
+		timingsFrom(ctx).since("cache_set", setStart)
 	}
+	return pdfBuf, nil
+}
 
-	requestID := c.Get("X-Request-ID")
-	logging.Info("PDF generated", "filename", params.Filename, "request_id", requestID)
+func (svc *PDFService) cacheEnabled() bool {
+	return svc.Redis != nil && svc.Config.Cache.PDFCacheEnabled
+}
+
+// renderTimeout is the render deadline for params: the requested timeout or pdf.timeout_secs.
+func (svc *PDFService) renderTimeout(params *PDFRequestParams) time.Duration {
+	return renderTimeout(*svc.Config, params.Timeout)
+}
+
+// renderTimeout returns requested, or pdf.timeout_secs when it is 0.
+func renderTimeout(cfg config.Config, requested time.Duration) time.Duration {
+	if requested > 0 {
+		return requested
+	}
+	return time.Duration(cfg.PDF.TimeoutSecs) * time.Second
+}
+
+// renderWaitTimeout bounds how long a follower waits for a render running on another replica.
+func (svc *PDFService) renderWaitTimeout() time.Duration {
+	return svc.longestRenderTimeout() + 5*time.Second
+}
 
-	c.Set("Content-Type", "application/pdf")
-	c.Set("Content-Disposition", "attachment; filename="+params.Filename)
-	return c.Send(pdfBuf)
+func (svc *PDFService) renderLockTTL() time.Duration {
+	if svc.Config.Cache.RenderLockTTL > 0 {
+		return svc.Config.Cache.RenderLockTTL
+	}
+	return svc.longestRenderTimeout() + 10*time.Second
+}
+
+// longestRenderTimeout is the longest timeout a render may run with: pdf.timeout_secs or, when
+// larger, render.max_timeout_secs.
+func (svc *PDFService) longestRenderTimeout() time.Duration {
+	return time.Duration(max(svc.Config.PDF.TimeoutSecs, svc.Config.Render.MaxTimeoutSecs)) * time.Second
 }
 
-func (svc *PDFService) renderPDF(params *PDFRequestParams) ([]byte, error) {
+// getRenderer returns the injected renderer or a Chrome-backed one derived from the configuration.
+func (svc *PDFService) getRenderer() (domain.Renderer, error) {
+	if svc.Renderer != nil {
+		return svc.Renderer, nil
+	}
 	pool, err := svc.getChromePool()
 	if err != nil {
 		return nil, err
 	}
 	if pool == nil {
 		// Fallback: start a new Chrome instance per request.
-		return renderPDFWithChrome(params.HTML, params.URL, params.Paper, params.Margin, *svc.Config)
+		return &chromeExecRenderer{cfg: svc.Config, opts: svc.renderOptions()}, nil
 	}
+	return &chromePoolRenderer{pool: pool, cfg: svc.Config, opts: svc.renderOptions()}, nil
+}
 
-	timeout := time.Duration(svc.Config.PDF.TimeoutSecs) * time.Second
-
-	runOnce := func() ([]byte, error) {
-		acquireCtx, acquireCancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer acquireCancel()
+// renderPDF renders params with the configured renderer. Cancelling ctx aborts the render,
+// whether it is still queued for a tab or already running in one.
+func (svc *PDFService) renderPDF(ctx context.Context, params *PDFRequestParams) ([]byte, error) {
+	renderer, err := svc.getRenderer()
+	if err != nil {
+		return nil, err
+	}
+	return renderer.Render(ctx, params.renderRequest())
+}
 
-		tab, err := pool.Acquire(acquireCtx)
-		if err != nil {
-			return nil, err
-		}
+// Ready reports whether the service can render. With the Chrome pool enabled, the pool is
+// started on first use (so readiness checks warm it up) and must have a healthy process.
+func (svc *PDFService) Ready(_ *fiber.Ctx) bool {
+	if svc.Renderer != nil {
+		return true
+	}
+	pool, err := svc.getChromePool()
+	if err != nil {
+		return false
+	}
+	if pool == nil {
+		return true // per-request Chrome; nothing long-lived to check
+	}
+	return pool.Ready()
+}
 
-		ctx, cancel := context.WithTimeout(tab.Ctx, timeout)
-		pdfBuf, renderErr := renderPDFInExistingTab(ctx, params.HTML, params.URL, params.Paper, params.Margin)
-		cancel()
+// Close releases the renderer (and with it the Chrome pool, if one was started).
+func (svc *PDFService) Close() error {
+	if svc.Renderer != nil {
+		return svc.Renderer.Close()
+	}
+	svc.poolMu.Lock()
+	pool := svc.pool
+	svc.pool = nil
+	svc.poolMu.Unlock()
+	if pool != nil {
+		pool.Close()
+	}
+	return nil
+}
 
-		pool.Release(tab, renderErr)
-		return pdfBuf, renderErr
+// renderRequest converts validated parameters into a renderer request.
+func (p *PDFRequestParams) renderRequest() domain.RenderRequest {
+	return domain.RenderRequest{
+		HTML:     p.HTML,
+		URL:      p.URL,
+		Paper:    p.Paper,
+		Margin:   p.Margin,
+		Tenant:   p.Tenant,
+		Priority: p.Priority,
+		Timeout:  p.Timeout,
+		Policy:   p.Policy,
+		CSS:      p.CSS,
+		Script:   p.Script,
 	}
+}
 
-	pdfBuf, renderErr := runOnce()
-	if renderErr != nil && chrome.IsSessionInterrupted(renderErr) {
-		logging.Warn("Chrome session interrupted; restarting pool and retrying once", "error", renderErr)
-		_ = pool.Restart()
-		return runOnce()
+// clone returns a deep copy of p. Strings read from the request point into buffers Fiber reuses
+// once the handler returns, and a shared render may outlive the request that started it.
+func (p *PDFRequestParams) clone() *PDFRequestParams {
+	cp := *p
+	for _, s := range []*string{&cp.HTML, &cp.URL, &cp.Format, &cp.Orientation, &cp.Filename, &cp.Tenant, &cp.Priority, &cp.Debug, &cp.CSS, &cp.Script} {
+		*s = strings.Clone(*s)
 	}
+	cp.Policy.BlockTypes = cloneStrings(p.Policy.BlockTypes)
+	cp.Policy.BlockURLs = cloneStrings(p.Policy.BlockURLs)
+	return &cp
+}
 
-	return pdfBuf, renderErr
+func cloneStrings(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := make([]string, len(in))
+	for i, s := range in {
+		out[i] = strings.Clone(s)
+	}
+	return out
 }
 
 // validateAndExtractPDFParams validates and parses input parameters from the HTTP request.
@@ -245,6 +634,31 @@ func validateAndExtractPDFParams(c *fiber.Ctx, cfg config.Config) (*PDFRequestPa
 		paper.Width, paper.Height = paper.Height, paper.Width
 	}
 
+	priority, err := extractPriority(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := extractTimeout(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	debug, err := extractDebug(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := extractResourcePolicy(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	styles, script, err := extractInjection(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &PDFRequestParams{
 		HTML:        html,
 		Format:      format,
@@ -252,6 +666,13 @@ func validateAndExtractPDFParams(c *fiber.Ctx, cfg config.Config) (*PDFRequestPa
 		Margin:      margin,
 		Filename:    filename,
 		Paper:       paper,
+		Tenant:      requestTenant(c),
+		Priority:    priority,
+		Timeout:     timeout,
+		Debug:       debug,
+		Policy:      policy,
+		CSS:         styles,
+		Script:      script,
 	}, nil
 }
 
@@ -312,6 +733,31 @@ func validateAndExtractURLParams(c *fiber.Ctx, cfg config.Config) (*PDFRequestPa
 		paper.Width, paper.Height = paper.Height, paper.Width
 	}
 
+	priority, err := extractPriority(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := extractTimeout(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	debug, err := extractDebug(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := extractResourcePolicy(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	styles, script, err := extractInjection(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &PDFRequestParams{
 		URL:         urlStr,
 		Format:      format,
@@ -319,9 +765,61 @@ func validateAndExtractURLParams(c *fiber.Ctx, cfg config.Config) (*PDFRequestPa
 		Margin:      margin,
 		Filename:    filename,
 		Paper:       paper,
+		Tenant:      requestTenant(c),
+		Priority:    priority,
+		Timeout:     timeout,
+		Debug:       debug,
+		Policy:      policy,
+		CSS:         styles,
+		Script:      script,
 	}, nil
 }
 
+// extractPriority reads the render priority from the "priority" parameter or the
+// X-Render-Priority header (the parameter wins). It defaults to interactive, which is granted
+// only to callers holding one of the scheduler.interactive_scopes; others are downgraded to batch.
+func extractPriority(c *fiber.Ctx, cfg config.Config) (string, error) {
+	v := c.FormValue("priority")
+	if v == "" {
+		v = c.Get("X-Render-Priority")
+	}
+	pr, ok := chrome.ParsePriority(v)
+	if !ok {
+		return "", fiber.NewError(fiber.StatusBadRequest, "Invalid priority: must be 'interactive' or 'batch'")
+	}
+	if pr == chrome.PriorityInteractive && !scopeAllowed(c, cfg.Scheduler.InteractiveScopes) {
+		pr = chrome.PriorityBatch
+	}
+	return pr.String(), nil
+}
+
+// extractTimeout reads the "timeout" parameter: the render timeout in seconds, at most
+// render.max_timeout_secs (default pdf.timeout_secs). Without it the render uses pdf.timeout_secs.
+func extractTimeout(c *fiber.Ctx, cfg config.Config) (time.Duration, error) {
+	v := strings.TrimSpace(c.FormValue("timeout"))
+	if v == "" {
+		return 0, nil
+	}
+	limit := cfg.Render.MaxTimeoutSecs
+	if limit <= 0 {
+		limit = cfg.PDF.TimeoutSecs
+	}
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs < 1 || secs > limit {
+		return 0, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid timeout: must be an integer between 1 and %d seconds", limit))
+	}
+	return time.Duration(secs) * time.Second, nil
+}
+
+// requestTenant identifies the caller for fair scheduling: the subject forwarded by the
+// gateway after authentication, falling back to the client IP.
+func requestTenant(c *fiber.Ctx) string {
+	if subject := strings.TrimSpace(c.Get("X-Auth-Subject")); subject != "" {
+		return subject
+	}
+	return "ip:" + c.IP()
+}
+
 // computePDFCacheKey creates a SHA256-based cache key based on input parameters.
 func computePDFCacheKey(params *PDFRequestParams) string {
 	h := sha256.New()
@@ -333,11 +831,13 @@ func computePDFCacheKey(params *PDFRequestParams) string {
 	h.Write([]byte(params.Format))
 	h.Write([]byte(params.Orientation))
 	h.Write([]byte(strconv.FormatFloat(params.Margin, 'f', 2, 64)))
+	h.Write([]byte(policyCacheKey(params.Policy)))
+	h.Write([]byte(injectionCacheKey(params.CSS, params.Script)))
 	return "pdfcache:" + hex.EncodeToString(h.Sum(nil))
 }
 
 // getCachedPDF attempts to retrieve a cached PDF from Redis.
-func getCachedPDF(c *fiber.Ctx, rdb *redis.Client, key, filename string) ([]byte, error) {
+func getCachedPDF(c *fiber.Ctx, rdb *redis.Client, key string) ([]byte, error) {
 	ctxRedis, cancel := context.WithTimeout(c.Context(), 1*time.Second)
 	defer cancel()
 
@@ -351,14 +851,12 @@ func getCachedPDF(c *fiber.Ctx, rdb *redis.Client, key, filename string) ([]byte
 	}
 
 	logging.Info("PDF cache hit", "key", key)
-	c.Set("Content-Type", "application/pdf")
-	c.Set("Content-Disposition", "attachment; filename="+filename)
 	return cached, nil
 }
 
 // setCachedPDF stores a PDF in Redis for 24 hours.
-func setCachedPDF(c *fiber.Ctx, rdb *redis.Client, key string, data []byte, ttl time.Duration) {
-	ctxRedis, cancel := context.WithTimeout(c.Context(), 1*time.Second)
+func setCachedPDF(ctx context.Context, rdb *redis.Client, key string, data []byte, ttl time.Duration) {
+	ctxRedis, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
 	if ttl <= 0 {
@@ -371,58 +869,75 @@ func setCachedPDF(c *fiber.Ctx, rdb *redis.Client, key string, data []byte, ttl
 }
 
 // renderPDFWithChrome uses headless Chrome via chromedp to render the HTML to PDF.
-func renderPDFWithChrome(html, url string, paper config.PaperSize, margin float64, cfg config.Config) ([]byte, error) {
+// In remote mode it opens a dedicated connection to the external Chromium instead of launching one.
+func renderPDFWithChrome(ctx context.Context, req domain.RenderRequest, cfg config.Config, opts renderOptions) ([]byte, error) {
+	var pdfBuf []byte
+	err := runInNewChrome(ctx, cfg, req.Timeout, func(tabCtx context.Context) error {
+		var err error
+		pdfBuf, err = renderPDFInExistingTab(tabCtx, req, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pdfBuf, nil
+}
+
+// runInNewChrome runs fn in the tab of a Chrome started for this call (or, in remote mode, a
+// dedicated connection to the external Chromium), bounded by timeout (0: pdf.timeout_secs).
+// Cancelling ctx terminates the browser.
+func runInNewChrome(ctx context.Context, cfg config.Config, timeout time.Duration, fn func(tabCtx context.Context) error) error {
+	timeout = renderTimeout(cfg, timeout)
+	if chrome.IsRemote(cfg) {
+		allocCtx, allocCancel := chrome.NewRemoteAllocator(ctx, cfg)
+		defer allocCancel()
+		chromeCtx, cancel := chromedp.NewContext(allocCtx)
+		defer cancel()
+		chromeCtx, cancel = context.WithTimeout(chromeCtx, timeout)
+		defer cancel()
+		return fn(chromeCtx)
+	}
 
 	tmpDir, err := os.MkdirTemp("", "chromedata-*")
 	if err != nil {
-		return nil, fmt.Errorf("cannot create temp profile dir: %w", err)
+		return fmt.Errorf("cannot create temp profile dir: %w", err)
 	}
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 
-	allocatorOptions := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.UserDataDir(tmpDir),
-		// Force software rendering and avoid Vulkan/ANGLE issues in minimal container environments.
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("disable-gpu-compositing", true),
-		chromedp.Flag("disable-features", "Vulkan,UseSkiaRenderer"),
-		chromedp.Flag("use-gl", "swiftshader"),
-		chromedp.Flag("disable-dev-shm-usage", true),
-	)
-	if cfg.PDF.ChromePath != "" {
-		allocatorOptions = append(allocatorOptions, chromedp.ExecPath(cfg.PDF.ChromePath))
-	}
-	if cfg.PDF.ChromeNoSandbox {
-		allocatorOptions = append(allocatorOptions, chromedp.Flag("no-sandbox", true))
+	allocatorOptions, err := chrome.ExecAllocatorOptions(cfg, tmpDir)
+	if err != nil {
+		return err
 	}
 
-	allocCtx, _ := chromedp.NewExecAllocator(context.Background(), allocatorOptions...)
+	allocCtx, _ := chromedp.NewExecAllocator(ctx, allocatorOptions...)
 	chromeCtx, cancel := chromedp.NewContext(allocCtx)
 	defer cancel()
 
-	timeout := time.Duration(cfg.PDF.TimeoutSecs) * time.Second
 	chromeCtx, cancel = context.WithTimeout(chromeCtx, timeout)
 	defer cancel()
 
-	pdfBuf, err := renderPDFInExistingTab(chromeCtx, html, url, paper, margin)
-
-	if err != nil {
-		return nil, err
-	}
-	return pdfBuf, nil
+	return fn(chromeCtx)
 }
 
 // renderPDFInExistingTab renders either raw HTML or a remote URL into PDF within a pre-existing chromedp tab.
-func renderPDFInExistingTab(ctx context.Context, html, url string, paper config.PaperSize, margin float64) ([]byte, error) {
+// The tab must be fresh: the resource policy stays in effect for the tab's lifetime.
+func renderPDFInExistingTab(ctx context.Context, req domain.RenderRequest, opts renderOptions) ([]byte, error) {
+	if d := diagnosticsFrom(ctx); d != nil {
+		chromedp.ListenTarget(ctx, d.handleEvent)
+	}
+
 	var pdfBuf []byte
-	var actions []chromedp.Action
+	actions := interceptRequests(ctx, req.Policy, opts)
+	html, paper, margin := req.HTML, req.Paper, req.Margin
 
-	if url != "" {
-		actions = append(actions,
-			chromedp.Navigate(url),
+	if req.URL != "" {
+		actions = append(actions, traced("chrome.navigate",
+			chromedp.Navigate(req.URL),
 			chromedp.WaitReady("body", chromedp.ByQuery),
-		)
+			injectFonts(opts.fonts),
+		))
 	} else {
-		actions = append(actions,
+		actions = append(actions, traced("chrome.navigate",
 			chromedp.Navigate("about:blank"),
 			chromedp.ActionFunc(func(ctx context.Context) error {
 				frame, err := page.GetFrameTree().Do(ctx)
@@ -432,14 +947,23 @@ func renderPDFInExistingTab(ctx context.Context, html, url string, paper config.
 				return page.SetDocumentContent(frame.Frame.ID, html).Do(ctx)
 			}),
 			chromedp.WaitReady("body", chromedp.ByQuery),
-		)
+			injectFonts(opts.fonts),
+		))
 	}
 
+	if req.CSS != "" || req.Script != "" {
+		actions = append(actions, traced("chrome.inject", injectContent(req.CSS, req.Script)))
+	}
 	actions = append(actions,
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		traced("chrome.wait_ready", chromedp.ActionFunc(func(ctx context.Context) error {
 			return waitForRenderReady(ctx, 15*time.Second)
-		}),
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		})),
+	)
+	if opts.checkFonts {
+		actions = append(actions, traced("chrome.check_fonts", checkFontFallbacks()))
+	}
+	actions = append(actions,
+		traced("chrome.print_to_pdf", chromedp.ActionFunc(func(ctx context.Context) error {
 			var err error
 			pdfBuf, _, err = page.PrintToPDF().
 				WithPrintBackground(true).
@@ -451,7 +975,7 @@ func renderPDFInExistingTab(ctx context.Context, html, url string, paper config.
 				WithMarginRight(margin).
 				Do(ctx)
 			return err
-		}),
+		})),
 	)
 
 	if err := chromedp.Run(ctx, actions...); err != nil {
@@ -460,64 +984,78 @@ func renderPDFInExistingTab(ctx context.Context, html, url string, paper config.
 	return pdfBuf, nil
 }
 
+// traced runs actions inside a child span of the trace carried by the chromedp context and
+// records their duration as a render phase named after the span ("chrome.navigate" → "navigate").
+func traced(name string, actions ...chromedp.Action) chromedp.Action {
+	phase := name[strings.LastIndexByte(name, '.')+1:]
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		start := time.Now()
+		ctx, span := tracing.Start(ctx, name)
+		err := chromedp.Tasks(actions).Do(ctx)
+		tracing.End(span, err)
+		timingsFrom(ctx).since(phase, start)
+		return err
+	})
+}
+
+// renderReadyPhases are the conditions waitForRenderReady waits for, in order.
+var renderReadyPhases = []struct {
+	name string
+	expr string
+}{
+	// 1) Document readyState
+	{"ready_state", `document.readyState === "complete"`},
+	// 2) Optional explicit hook: allow examples to signal "I'm ready"
+	// If the flag is undefined, we don't block on it.
+	{"ready_hook", `(typeof window.__HTML2PDF_READY__ === "undefined") || (window.__HTML2PDF_READY__ === true)`},
+	// 3) Fonts loaded (if Font Loading API exists)
+	{"fonts", `(document.fonts && document.fonts.status) ? (document.fonts.status === "loaded") : true`},
+	// 4) Images loaded (complete==true means loaded or failed; we mainly avoid "still downloading")
+	{"images", `Array.from(document.images || []).every(img => img.complete)`},
+}
+
 // waitForRenderReady waits until the page finished loading and critical assets are available.
 // This avoids rendering PDFs before CDN assets (CSS/fonts/images) are loaded.
+// All phases share one deadline; a phase that runs out of time does not fail the render, but is
+// reported in the request timings and the remaining phases are skipped.
 func waitForRenderReady(ctx context.Context, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
-
-	// 1) Document readyState
-	for time.Now().Before(deadline) {
-		var state string
-		if err := chromedp.Evaluate(`document.readyState`, &state).Do(ctx); err != nil {
+	for _, phase := range renderReadyPhases {
+		ready, err := waitForCondition(ctx, phase.name, phase.expr, deadline)
+		if err != nil {
 			return err
 		}
-		if state == "complete" {
-			break
+		if !ready {
+			timingsFrom(ctx).markReadyTimeout(phase.name)
+			return nil
 		}
-		time.Sleep(100 * time.Millisecond)
 	}
+	return nil
+}
 
-	// 2) Optional explicit hook: allow examples to signal "I'm ready"
-	// If the flag is undefined, we don't block on it.
+// waitForCondition polls expr until it evaluates to true (ready) or the deadline passes, in a
+// span named after the phase. The phase duration is recorded in the request timings.
+func waitForCondition(ctx context.Context, phase, expr string, deadline time.Time) (bool, error) {
+	start := time.Now()
+	timings := timingsFrom(ctx)
+	defer func() { timings.since(readyPhaseName(phase), start) }()
+
+	ctx, span := tracing.Start(ctx, "chrome.wait_ready."+phase)
 	for time.Now().Before(deadline) {
 		var ok bool
-		expr := `(typeof window.__HTML2PDF_READY__ === "undefined") || (window.__HTML2PDF_READY__ === true)`
 		if err := chromedp.Evaluate(expr, &ok).Do(ctx); err != nil {
-			return err
+			tracing.End(span, err)
+			return false, err
 		}
 		if ok {
-			break
+			span.End()
+			return true, nil
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
-
-	// 3) Fonts loaded (if Font Loading API exists)
-	for time.Now().Before(deadline) {
-		var loaded bool
-		expr := `(document.fonts && document.fonts.status) ? (document.fonts.status === "loaded") : true`
-		if err := chromedp.Evaluate(expr, &loaded).Do(ctx); err != nil {
-			return err
-		}
-		if loaded {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-
-	// 4) Images loaded (complete==true means loaded or failed; we mainly avoid "still downloading")
-	for time.Now().Before(deadline) {
-		var done bool
-		expr := `Array.from(document.images || []).every(img => img.complete)`
-		if err := chromedp.Evaluate(expr, &done).Do(ctx); err != nil {
-			return err
-		}
-		if done {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-
-	return nil
+	span.SetAttributes(attribute.Bool("render_ready.timed_out", true))
+	span.End()
+	return false, nil
 }
 
 // HandleChromeStats exposes basic observability for the Chrome pool (capacity / idle / in_use).
@@ -552,5 +1090,8 @@ func (svc *PDFService) HandleChromeStats(c *fiber.Ctx) error {
 		"timeout_secs":   svc.Config.PDF.TimeoutSecs,
 		"restarts":       s.Restarts,
 		"last_restart":   s.LastRestart,
+		"ready":          s.Ready,
+		"processes":      s.Processes,
+		"queue":          s.Queue,
 	})
 }
@@ -121,7 +121,7 @@ func TestRenderPDF_UsesExistingPoolAcquireFailure(t *testing.T) {
 	// emulate closed pool error path
 	ctxPool.Close()
 	svc.pool = ctxPool
-	_, err := svc.renderPDF(&PDFRequestParams{HTML: "<html>hello world</html>", Paper: cfg.PDF.PaperSizes["A4"], Margin: 0.4})
+	_, err := svc.renderPDF(context.Background(), &PDFRequestParams{HTML: "<html>hello world</html>", Paper: cfg.PDF.PaperSizes["A4"], Margin: 0.4})
 	if err == nil {
 		t.Fatalf("expected render error when pool cannot acquire")
 	}
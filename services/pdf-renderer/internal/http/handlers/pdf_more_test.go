@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"io"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -12,6 +13,8 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"pdf-renderer/internal/config"
+	"pdf-renderer/internal/domain"
+	"pdf-renderer/internal/infra/fake"
 )
 
 func testPDFCfg() config.Config {
@@ -120,7 +123,7 @@ func TestSetCachedPDF_DefaultTTLAndProcessCacheHit(t *testing.T) {
 
 	app := fiber.New()
 	app.Get("/cache", func(c *fiber.Ctx) error {
-		setCachedPDF(c, rdb, "k", []byte("pdf"), 0)
+		setCachedPDF(c.Context(), rdb, "k", []byte("pdf"), 0)
 		ttl := mrs.TTL("k")
 		if ttl < 50*time.Second || ttl > 70*time.Second {
 			t.Fatalf("expected default ttl around 1m, got %v", ttl)
@@ -146,7 +149,7 @@ func TestSetCachedPDF_DefaultTTLAndProcessCacheHit(t *testing.T) {
 func TestRenderPDFWithChrome_ErrorWhenBinaryMissing(t *testing.T) {
 	cfg := testPDFCfg()
 	cfg.PDF.ChromePath = "/definitely/missing/chrome"
-	_, err := renderPDFWithChrome("<html>hello world</html>", "", cfg.PDF.PaperSizes["A4"], 0.4, cfg)
+	_, err := renderPDFWithChrome(context.Background(), domain.RenderRequest{HTML: "<html>hello world</html>", Paper: cfg.PDF.PaperSizes["A4"], Margin: 0.4}, cfg, renderOptions{})
 	if err == nil {
 		t.Fatalf("expected render error with missing chrome binary")
 	}
@@ -155,7 +158,7 @@ func TestRenderPDFWithChrome_ErrorWhenBinaryMissing(t *testing.T) {
 func TestRenderPDFInExistingTab_ContextCanceled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
-	_, err := renderPDFInExistingTab(ctx, "<html>hello world</html>", "", config.PaperSize{Width: 8.27, Height: 11.69}, 0.4)
+	_, err := renderPDFInExistingTab(ctx, domain.RenderRequest{HTML: "<html>hello world</html>", Paper: config.PaperSize{Width: 8.27, Height: 11.69}, Margin: 0.4}, renderOptions{})
 	if err == nil {
 		t.Fatalf("expected canceled-context error")
 	}
@@ -168,3 +171,68 @@ func TestWaitForRenderReady_ContextCanceled(t *testing.T) {
 		t.Fatalf("expected canceled-context error")
 	}
 }
+
+func TestExtractTimeout(t *testing.T) {
+	cfg := testPDFCfg()
+	cfg.PDF.TimeoutSecs = 30
+	cfg.Render.MaxTimeoutSecs = 60
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		d, err := extractTimeout(c, cfg)
+		if err != nil {
+			return err
+		}
+		return c.SendString(d.String())
+	})
+
+	tests := []struct {
+		target string
+		code   int
+		want   string
+	}{
+		{"/", fiber.StatusOK, "0s"},
+		{"/?timeout=5", fiber.StatusOK, "5s"},
+		{"/?timeout=60", fiber.StatusOK, "1m0s"},
+		{"/?timeout=61", fiber.StatusBadRequest, ""},
+		{"/?timeout=0", fiber.StatusBadRequest, ""},
+		{"/?timeout=1.5", fiber.StatusBadRequest, ""},
+	}
+	for _, tc := range tests {
+		resp, err := app.Test(httptest.NewRequest("GET", tc.target, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != tc.code {
+			t.Fatalf("%s: expected %d, got %d", tc.target, tc.code, resp.StatusCode)
+		}
+		if body, _ := io.ReadAll(resp.Body); tc.code == fiber.StatusOK && string(body) != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.target, tc.want, body)
+		}
+	}
+
+	// Without render.max_timeout_secs the limit is pdf.timeout_secs.
+	cfg.Render.MaxTimeoutSecs = 0
+	resp, _ := app.Test(httptest.NewRequest("GET", "/?timeout=31", nil))
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("expected timeouts above pdf.timeout_secs to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestRenderError_ClientCancelled(t *testing.T) {
+	svc, _ := newFakeService(t, fake.NewRenderer())
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancel(c.UserContext())
+		cancel()
+		c.SetUserContext(ctx)
+		return svc.renderError(c, &PDFRequestParams{}, context.Canceled)
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != statusClientClosedRequest {
+		t.Fatalf("expected %d, got %d", statusClientClosedRequest, resp.StatusCode)
+	}
+}
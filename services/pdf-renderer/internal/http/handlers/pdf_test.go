@@ -80,10 +80,10 @@ func Test_setAndGetCachedPDF(t *testing.T) {
 		key := "testcachekey"
 		data := []byte("PDFDATA123")
 
-		setCachedPDF(c, rdb, key, data, 1*time.Minute)
+		setCachedPDF(c.Context(), rdb, key, data, 1*time.Minute)
 
 		// Retrieve immediately
-		result, err := getCachedPDF(c, rdb, key, "test.pdf")
+		result, err := getCachedPDF(c, rdb, key)
 		if err != nil {
 			t.Errorf("unexpected error on getCachedPDF: %v", err)
 			return err
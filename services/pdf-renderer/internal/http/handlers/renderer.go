@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"pdf-renderer/internal/config"
+	"pdf-renderer/internal/domain"
+	"pdf-renderer/internal/infra/assetcache"
+	"pdf-renderer/internal/infra/chrome"
+	"pdf-renderer/internal/infra/fonts"
+	"pdf-renderer/internal/infra/logging"
+	"pdf-renderer/internal/infra/metrics"
+	"pdf-renderer/internal/infra/tracing"
+)
+
+// renderOptions are the long-lived resources renders draw on besides Chrome.
+type renderOptions struct {
+	assets     *assetcache.Cache // serves sub-resources; nil without an asset cache
+	fonts      *fonts.Library    // custom fonts injected as @font-face rules; nil without a fonts directory
+	checkFonts bool              // report font families that fell back (render.report_font_fallbacks)
+}
+
+// tabRunner is implemented by the Chrome-backed renderers: it runs fn in a fresh tab, for
+// inspections that are not renders (see HandleFonts).
+type tabRunner interface {
+	runInTab(ctx context.Context, fn func(tabCtx context.Context) error) error
+}
+
+// chromePoolRenderer renders in fresh tabs of the shared Chrome pool.
+type chromePoolRenderer struct {
+	pool *chrome.Pool
+	cfg  *config.Config
+	opts renderOptions
+}
+
+// Render acquires a tab, renders and releases it. When the Chrome session breaks,
+// the affected Chrome process is restarted and the render retried once.
+// Waiting for a tab is bounded by the scheduler's per-class wait timeout, rendering by the
+// request's timeout. Cancelling ctx leaves the queue or closes the tab right away.
+func (r *chromePoolRenderer) Render(ctx context.Context, req domain.RenderRequest) ([]byte, error) {
+	timeout := renderTimeout(*r.cfg, req.Timeout)
+	priority, _ := chrome.ParsePriority(req.Priority)
+	opts := chrome.AcquireOptions{Tenant: req.Tenant, Priority: priority}
+
+	runOnce := func() (*chrome.Tab, []byte, error) {
+		acquireStart := time.Now()
+		acquireCtx, span := tracing.Start(ctx, "chrome.acquire",
+			attribute.String("render.tenant", req.Tenant),
+			attribute.String("render.priority", priority.String()),
+		)
+		tab, err := r.pool.AcquireFor(acquireCtx, opts)
+		if tab != nil {
+			span.SetAttributes(
+				attribute.Int("chrome.process", tab.Process()),
+				attribute.Int("queue.position", tab.QueuePosition),
+				attribute.Int64("queue.wait_ms", tab.QueueWait.Milliseconds()),
+			)
+		}
+		tracing.End(span, err)
+		timingsFrom(ctx).since("acquire", acquireStart)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tab.QueuePosition > 0 {
+			logging.Info("Render dequeued", "tenant", req.Tenant, "priority", priority.String(), "queue_position", tab.QueuePosition, "queue_wait_ms", tab.QueueWait.Milliseconds())
+		}
+
+		tabCtx, cancel := context.WithTimeout(tab.Ctx, timeout)
+		stop := context.AfterFunc(ctx, cancel)
+		tabCtx = requestScoped(tabCtx, ctx)
+		pdfBuf, renderErr := renderPDFInExistingTab(tabCtx, req, r.opts)
+		stop()
+		cancel()
+
+		r.pool.Release(tab, renderErr)
+		return tab, pdfBuf, renderErr
+	}
+
+	tab, pdfBuf, renderErr := runOnce()
+	if ctx.Err() != nil {
+		return nil, ctx.Err() // cancelled: the tab was closed on purpose
+	}
+	// A timeout the client chose is the page being slow, not a wedged Chrome.
+	clientTimeout := req.Timeout > 0 && errors.Is(renderErr, context.DeadlineExceeded)
+	if renderErr != nil && tab != nil && !clientTimeout && chrome.IsSessionInterrupted(renderErr) {
+		metrics.SessionInterruptions.Inc()
+		logging.Warn("Chrome session interrupted; restarting process and retrying once", "process", tab.Process(), "error", renderErr)
+		trace.SpanFromContext(ctx).AddEvent("chrome session interrupted; retrying")
+		_ = r.pool.RestartFor(tab)
+		_, pdfBuf, renderErr = runOnce()
+	}
+
+	return pdfBuf, renderErr
+}
+
+func (r *chromePoolRenderer) runInTab(ctx context.Context, fn func(tabCtx context.Context) error) error {
+	tab, err := r.pool.AcquireFor(ctx, chrome.AcquireOptions{Tenant: "ops", Priority: chrome.PriorityInteractive})
+	if err != nil {
+		return err
+	}
+	tabCtx, cancel := context.WithTimeout(tab.Ctx, renderTimeout(*r.cfg, 0))
+	stop := context.AfterFunc(ctx, cancel)
+	err = fn(tabCtx)
+	stop()
+	cancel()
+	r.pool.Release(tab, err)
+	return err
+}
+
+func (r *chromePoolRenderer) Stats() domain.RendererStats {
+	s := r.pool.Stats(r.cfg.PDF.TimeoutSecs)
+	return domain.RendererStats{
+		Backend:  "chrome-pool",
+		Capacity: s.Capacity,
+		InUse:    s.InUse,
+	}
+}
+
+func (r *chromePoolRenderer) Close() error {
+	r.pool.Close()
+	return nil
+}
+
+// chromeExecRenderer starts a dedicated Chrome process per render (pooling disabled).
+type chromeExecRenderer struct {
+	cfg  *config.Config
+	opts renderOptions
+}
+
+func (r *chromeExecRenderer) Render(ctx context.Context, req domain.RenderRequest) ([]byte, error) {
+	return renderPDFWithChrome(ctx, req, *r.cfg, r.opts)
+}
+
+func (r *chromeExecRenderer) runInTab(ctx context.Context, fn func(tabCtx context.Context) error) error {
+	return runInNewChrome(ctx, *r.cfg, 0, fn)
+}
+
+func (r *chromeExecRenderer) Stats() domain.RendererStats {
+	return domain.RendererStats{Backend: "chrome-exec"}
+}
+
+func (r *chromeExecRenderer) Close() error {
+	return nil
+}
+
+// requestScoped carries the request's trace span, timings recorder and diagnostics collector
+// over to ctx, a tab context that descends from the browser rather than the request.
+func requestScoped(ctx, req context.Context) context.Context {
+	ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(req))
+	if t := timingsFrom(req); t != nil {
+		ctx = context.WithValue(ctx, renderTimingsKey{}, t)
+	}
+	if d := diagnosticsFrom(req); d != nil {
+		ctx = context.WithValue(ctx, renderDiagnosticsKey{}, d)
+	}
+	return ctx
+}
+
+// compile-time checks
+var (
+	_ domain.Renderer = (*chromePoolRenderer)(nil)
+	_ domain.Renderer = (*chromeExecRenderer)(nil)
+	_ tabRunner       = (*chromePoolRenderer)(nil)
+	_ tabRunner       = (*chromeExecRenderer)(nil)
+)
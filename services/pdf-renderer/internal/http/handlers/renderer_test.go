@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"pdf-renderer/internal/config"
+	"pdf-renderer/internal/infra/chrome"
+	"pdf-renderer/internal/infra/fake"
+	"pdf-renderer/internal/infra/metrics"
+)
+
+func newFakeService(t *testing.T, r *fake.Renderer) (*PDFService, *fiber.App) {
+	t.Helper()
+	cfg := testPDFCfg()
+	cfg.Cache.PDFCacheEnabled = false
+
+	svc := NewPDFService(cfg, nil)
+	svc.Renderer = r
+
+	app := fiber.New()
+	app.Post("/pdf", svc.HandleConversion)
+	app.Get("/pdf", svc.HandleURLConversion)
+	return svc, app
+}
+
+func TestHandleConversion_WithFakeRenderer(t *testing.T) {
+	r := fake.NewRenderer()
+	_, app := newFakeService(t, r)
+	metrics.RenderDuration.Reset()
+
+	req := httptest.NewRequest("POST", "/pdf", strings.NewReader("html=<html><body>hello world</body></html>&filename=doc.pdf"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/pdf" {
+		t.Fatalf("unexpected content type %q", got)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != "attachment; filename=doc.pdf" {
+		t.Fatalf("unexpected content disposition %q", got)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !bytes.HasPrefix(body, []byte("%PDF-")) {
+		t.Fatalf("expected PDF body")
+	}
+	if r.Renders() != 1 {
+		t.Fatalf("expected one render, got %d", r.Renders())
+	}
+	if n := testutil.CollectAndCount(metrics.RenderDuration); n != 1 {
+		t.Fatalf("expected render latency recorded for one label set, got %d", n)
+	}
+}
+
+func TestHandleURLConversion_CoalescesConcurrentRequests(t *testing.T) {
+	r := &fake.Renderer{Delay: 100 * time.Millisecond}
+	_, app := newFakeService(t, r)
+
+	const clients = 5
+	var wg sync.WaitGroup
+	bodies := make([][]byte, clients)
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := app.Test(httptest.NewRequest("GET", "/pdf?url=https://example.com/campaign", nil), 5000)
+			if err != nil {
+				t.Errorf("request failed: %v", err)
+				return
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Errorf("expected 200, got %d", resp.StatusCode)
+			}
+			bodies[i], _ = io.ReadAll(resp.Body)
+		}(i)
+	}
+	wg.Wait()
+
+	if got := r.Renders(); got != 1 {
+		t.Fatalf("expected concurrent identical requests to share one render, got %d", got)
+	}
+	for i := 1; i < clients; i++ {
+		if !bytes.Equal(bodies[0], bodies[i]) {
+			t.Fatalf("expected identical bodies for coalesced requests")
+		}
+	}
+}
+
+func TestHandleConversion_RendererErrorsMapToStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"timeout", context.DeadlineExceeded, fiber.StatusRequestTimeout},
+		{"session interrupted", errors.New("target closed"), fiber.StatusServiceUnavailable},
+		{"queue full", chrome.ErrQueueFull, fiber.StatusServiceUnavailable},
+		{"queue timeout", chrome.ErrQueueTimeout, fiber.StatusServiceUnavailable},
+		{"other", errors.New("boom"), fiber.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, app := newFakeService(t, &fake.Renderer{Err: tc.err})
+			req := httptest.NewRequest("POST", "/pdf", strings.NewReader("html=<html><body>hello world</body></html>"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != tc.code {
+				t.Fatalf("expected %d, got %d", tc.code, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHandleConversion_PDFTooLarge(t *testing.T) {
+	svc, app := newFakeService(t, fake.NewRenderer())
+	svc.Config.Limits.MaxPDFBytes = 10
+
+	req := httptest.NewRequest("POST", "/pdf", strings.NewReader("html=<html><body>hello world</body></html>"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", resp.StatusCode)
+	}
+}
+
+func TestPDFService_CloseClosesRenderer(t *testing.T) {
+	r := fake.NewRenderer()
+	svc, _ := newFakeService(t, r)
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, err := svc.renderPDF(context.Background(), &PDFRequestParams{HTML: "<html>x</html>"}); err == nil {
+		t.Fatalf("expected render to fail after close")
+	}
+}
+
+func TestHandleURLConversion_Priority(t *testing.T) {
+	_, app := newFakeService(t, fake.NewRenderer())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/pdf?url=https://example.com&priority=urgent", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown priority, got %d", resp.StatusCode)
+	}
+
+	req := httptest.NewRequest("GET", "/pdf?url=https://example.com", nil)
+	req.Header.Set("X-Render-Priority", "batch")
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200 for batch priority, got %d", resp.StatusCode)
+	}
+}
+
+func TestRequestTenantAndPriority(t *testing.T) {
+	var cfg config.Config
+	cfg.Scheduler.InteractiveScopes = []string{"interactive"}
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		pr, err := extractPriority(c, cfg)
+		if err != nil {
+			return err
+		}
+		return c.SendString(requestTenant(c) + "|" + pr)
+	})
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    string
+	}{
+		{"public callers run as batch", "/", nil, "ip:0.0.0.0|batch"},
+		{"subject and header", "/", map[string]string{"X-Auth-Subject": "key:abc", "X-Render-Priority": "batch"}, "key:abc|batch"},
+		{"interactive scope", "/", map[string]string{"X-Auth-Mode": "token", "X-Auth-Scopes": "api,interactive"}, "ip:0.0.0.0|interactive"},
+		{"interactive without the scope is downgraded", "/?priority=interactive", map[string]string{"X-Auth-Mode": "token", "X-Auth-Scopes": "api"}, "ip:0.0.0.0|batch"},
+		{"parameter wins", "/?priority=batch", map[string]string{"X-Auth-Mode": "token", "X-Auth-Scopes": "interactive", "X-Render-Priority": "interactive"}, "ip:0.0.0.0|batch"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.target, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, body)
+			}
+		})
+	}
+}
+
+func TestExtractPriority_ShippedConfigKeepsTokenCallersInteractive(t *testing.T) {
+	cfg := config.LoadFrom("../../../config/html2pdf.yaml")
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		pr, err := extractPriority(c, cfg)
+		if err != nil {
+			return err
+		}
+		return c.SendString(pr)
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		// Keys created by the admin API and authctl carry the "api" scope.
+		{"token caller", map[string]string{"X-Auth-Mode": "token", "X-Auth-Scopes": "api"}, "interactive"},
+		{"public caller", map[string]string{"X-Auth-Mode": "public", "X-Auth-Scopes": ""}, "batch"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?priority=interactive", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tc.want {
+				t.Fatalf("expected priority %q, got %q", tc.want, body)
+			}
+		})
+	}
+}
+
+func TestPDFService_Ready(t *testing.T) {
+	svc, _ := newFakeService(t, fake.NewRenderer())
+	if !svc.Ready(nil) {
+		t.Fatalf("expected injected renderer to be ready")
+	}
+
+	exec := NewPDFService(testPDFCfg(), nil)
+	exec.Config.PDF.ChromePoolSize = 0
+	if !exec.Ready(nil) {
+		t.Fatalf("expected per-request Chrome mode to be ready")
+	}
+}
+
+func TestHandleURLConversion_RecordsSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	_, app := newFakeService(t, fake.NewRenderer())
+	resp, err := app.Test(httptest.NewRequest("GET", "/pdf?url=https://example.com", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var names []string
+	for _, s := range rec.Ended() {
+		names = append(names, s.Name())
+	}
+	if got := strings.Join(names, ","); got != "pdf.validate,pdf.render" {
+		t.Fatalf("unexpected spans %q", got)
+	}
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/config"
+	"pdf-renderer/internal/domain"
+	"pdf-renderer/internal/infra/assetcache"
+	"pdf-renderer/internal/infra/fonts"
+	"pdf-renderer/internal/infra/metrics"
+)
+
+const (
+	maxBlockURLPatterns     = 20
+	maxBlockURLPatternBytes = 500
+)
+
+// blockableTypes maps the resource types accepted by the block parameter to CDP resource types.
+// Documents cannot be blocked: that would block the page itself.
+var blockableTypes = map[string]network.ResourceType{
+	"image":      network.ResourceTypeImage,
+	"font":       network.ResourceTypeFont,
+	"stylesheet": network.ResourceTypeStylesheet,
+	"media":      network.ResourceTypeMedia,
+	"script":     network.ResourceTypeScript,
+	"xhr":        network.ResourceTypeXHR,
+	"fetch":      network.ResourceTypeFetch,
+}
+
+// trackerDomains is the built-in blocklist (render.block_trackers): analytics, tag managers and ad
+// networks that never affect the printed page but often delay the load event.
+var trackerDomains = []string{
+	"google-analytics.com",
+	"googletagmanager.com",
+	"googleadservices.com",
+	"googlesyndication.com",
+	"doubleclick.net",
+	"adservice.google.com",
+	"connect.facebook.net",
+	"bat.bing.com",
+	"clarity.ms",
+	"hotjar.com",
+	"mixpanel.com",
+	"segment.io",
+	"cdn.segment.com",
+	"amplitude.com",
+	"fullstory.com",
+	"js-agent.newrelic.com",
+	"nr-data.net",
+	"scorecardresearch.com",
+	"quantserve.com",
+	"amazon-adsystem.com",
+	"adnxs.com",
+	"criteo.com",
+	"criteo.net",
+	"taboola.com",
+	"outbrain.com",
+	"static.ads-twitter.com",
+	"snap.licdn.com",
+}
+
+// extractResourcePolicy reads the javascript, block and block_urls parameters and merges them
+// with the render.* defaults. Types and patterns are normalized and sorted so equivalent
+// requests share a cache key.
+func extractResourcePolicy(c *fiber.Ctx, cfg config.Config) (domain.ResourcePolicy, error) {
+	policy := domain.ResourcePolicy{
+		DisableJavaScript: cfg.Render.DisableJavaScript,
+		BlockTrackers:     cfg.Render.BlockTrackers,
+	}
+
+	if v := strings.TrimSpace(c.FormValue("javascript")); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return policy, fiber.NewError(fiber.StatusBadRequest, "Invalid javascript: must be 'true' or 'false'")
+		}
+		policy.DisableJavaScript = !enabled
+	}
+
+	types := append([]string{}, cfg.Render.BlockResourceTypes...)
+	types = append(types, splitList(c.FormValue("block"))...)
+	for _, t := range types {
+		t = strings.ToLower(t)
+		if _, ok := blockableTypes[t]; !ok {
+			return policy, fiber.NewError(fiber.StatusBadRequest, "Invalid block: unknown resource type '"+t+"'")
+		}
+		policy.BlockTypes = append(policy.BlockTypes, t)
+	}
+
+	patterns := splitList(c.FormValue("block_urls"))
+	if len(patterns) > maxBlockURLPatterns {
+		return policy, fiber.NewError(fiber.StatusBadRequest, "Invalid block_urls: at most "+strconv.Itoa(maxBlockURLPatterns)+" patterns")
+	}
+	for _, p := range patterns {
+		if len(p) > maxBlockURLPatternBytes {
+			return policy, fiber.NewError(fiber.StatusBadRequest, "Invalid block_urls: pattern too long")
+		}
+	}
+	policy.BlockURLs = append(append([]string{}, cfg.Render.BlockURLPatterns...), patterns...)
+
+	slices.Sort(policy.BlockTypes)
+	policy.BlockTypes = slices.Compact(policy.BlockTypes)
+	slices.Sort(policy.BlockURLs)
+	policy.BlockURLs = slices.Compact(policy.BlockURLs)
+	return policy, nil
+}
+
+// policyCacheKey returns the part of the cache key that depends on the resource policy; it is
+// empty for the default policy so existing cache entries stay valid.
+func policyCacheKey(p domain.ResourcePolicy) string {
+	var b strings.Builder
+	if p.DisableJavaScript {
+		b.WriteString("|js=off")
+	}
+	if len(p.BlockTypes) > 0 {
+		b.WriteString("|types=" + strings.Join(p.BlockTypes, ","))
+	}
+	if len(p.BlockURLs) > 0 {
+		b.WriteString("|urls=" + strings.Join(p.BlockURLs, ","))
+	}
+	if p.BlockTrackers {
+		b.WriteString("|trackers")
+	}
+	return b.String()
+}
+
+// fetchPatterns returns the Fetch interception patterns for p. Every request matching one of
+// them is failed.
+func fetchPatterns(p domain.ResourcePolicy) []*fetch.RequestPattern {
+	var patterns []*fetch.RequestPattern
+	types := p.BlockTypes
+	if p.DisableJavaScript && !slices.Contains(types, "script") {
+		// Scripts would not run anyway; don't spend time downloading them.
+		types = append(slices.Clone(types), "script")
+	}
+	for _, t := range types {
+		patterns = append(patterns, &fetch.RequestPattern{URLPattern: "*", ResourceType: blockableTypes[t]})
+	}
+	for _, u := range p.BlockURLs {
+		patterns = append(patterns, &fetch.RequestPattern{URLPattern: u})
+	}
+	if p.BlockTrackers {
+		for _, d := range trackerDomains {
+			patterns = append(patterns,
+				&fetch.RequestPattern{URLPattern: "*://" + d + "/*"},
+				&fetch.RequestPattern{URLPattern: "*://*." + d + "/*"},
+			)
+		}
+	}
+	return patterns
+}
+
+// assetTypes are the resource types served through the asset cache when it is online; in offline
+// mode every sub-resource goes through it.
+var assetTypes = []network.ResourceType{
+	network.ResourceTypeStylesheet,
+	network.ResourceTypeScript,
+	network.ResourceTypeFont,
+	network.ResourceTypeImage,
+}
+
+// interceptRequests returns the actions that enforce p on the tab in ctx, serve the custom fonts
+// and, with an asset cache, route sub-resources through it. They must run before the page is loaded.
+func interceptRequests(ctx context.Context, p domain.ResourcePolicy, opts renderOptions) []chromedp.Action {
+	var actions []chromedp.Action
+	if p.DisableJavaScript {
+		actions = append(actions, emulation.SetScriptExecutionDisabled(true))
+	}
+	blocked := fetchPatterns(p)
+	patterns := append(slices.Clone(blocked), assetPatterns(opts.assets)...)
+	if opts.fonts != nil {
+		patterns = append(patterns, &fetch.RequestPattern{URLPattern: "*://" + fonts.Host + "/*"})
+	}
+	if len(patterns) == 0 {
+		return actions
+	}
+
+	chromedp.ListenTarget(ctx, func(ev any) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		// Listeners must not block the event loop; answer the paused request asynchronously.
+		go func() {
+			execCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+			if matchesAny(blocked, paused.Request.URL, paused.ResourceType) {
+				_ = fetch.FailRequest(paused.RequestID, network.ErrorReasonBlockedByClient).Do(execCtx)
+				return
+			}
+			if body, contentType, ok := opts.fonts.Open(paused.Request.URL); ok {
+				_ = fulfill(execCtx, paused.RequestID, http.StatusOK, http.Header{"Content-Type": {contentType}}, body)
+				return
+			}
+			// The main frame of a page target has the target's ID.
+			mainFrame := cdp.FrameID(chromedp.FromContext(ctx).Target.TargetID)
+			_ = serveAsset(execCtx, opts.assets, paused, mainFrame)
+		}()
+	})
+	return append(actions, fetch.Enable().WithPatterns(patterns))
+}
+
+func assetPatterns(assets *assetcache.Cache) []*fetch.RequestPattern {
+	if assets == nil {
+		return nil
+	}
+	if assets.Offline() {
+		return []*fetch.RequestPattern{{URLPattern: "*"}}
+	}
+	patterns := make([]*fetch.RequestPattern, 0, len(assetTypes))
+	for _, t := range assetTypes {
+		patterns = append(patterns, &fetch.RequestPattern{URLPattern: "*", ResourceType: t})
+	}
+	return patterns
+}
+
+// serveAsset answers a paused request from the asset cache. Documents are loaded by the browser,
+// except sub-frames in offline mode (see loadDocument); other requests the cache cannot serve are
+// loaded by the browser too, or fail in offline mode.
+func serveAsset(ctx context.Context, assets *assetcache.Cache, ev *fetch.EventRequestPaused, mainFrame cdp.FrameID) error {
+	if assets == nil {
+		return fetch.ContinueRequest(ev.RequestID).Do(ctx)
+	}
+	if ev.ResourceType == network.ResourceTypeDocument {
+		if !loadDocument(assets, ev, mainFrame) {
+			return fetch.FailRequest(ev.RequestID, network.ErrorReasonInternetDisconnected).Do(ctx)
+		}
+		return fetch.ContinueRequest(ev.RequestID).Do(ctx)
+	}
+	var entry *assetcache.Entry
+	err := assetcache.ErrUncacheable
+	if ev.Request.Method == http.MethodGet {
+		var result assetcache.Result
+		entry, result, err = assets.Get(ctx, ev.Request.URL, requestHeader(ev.Request.Headers))
+		metrics.AssetRequests.WithLabelValues(string(result)).Inc()
+	}
+	if err != nil {
+		if assets.Offline() {
+			return fetch.FailRequest(ev.RequestID, network.ErrorReasonInternetDisconnected).Do(ctx)
+		}
+		return fetch.ContinueRequest(ev.RequestID).Do(ctx)
+	}
+
+	return fulfill(ctx, ev.RequestID, entry.Status, entry.Header, entry.Body)
+}
+
+// loadDocument reports whether the browser may load a paused document request. In offline mode
+// only the top-level navigation is loaded; iframes and other sub-frame documents would reach the
+// network.
+func loadDocument(assets *assetcache.Cache, ev *fetch.EventRequestPaused, mainFrame cdp.FrameID) bool {
+	return !assets.Offline() || ev.FrameID == mainFrame
+}
+
+// fulfill answers a paused request. Responses are served with Access-Control-Allow-Origin: *;
+// they are public files, and the page using one need not be the one that fetched it first.
+func fulfill(ctx context.Context, id fetch.RequestID, status int, header http.Header, body []byte) error {
+	headers := make([]*fetch.HeaderEntry, 0, len(header)+1)
+	for name, values := range header {
+		if name == "Access-Control-Allow-Origin" {
+			continue
+		}
+		for _, v := range values {
+			headers = append(headers, &fetch.HeaderEntry{Name: name, Value: v})
+		}
+	}
+	headers = append(headers, &fetch.HeaderEntry{Name: "Access-Control-Allow-Origin", Value: "*"})
+	return fetch.FulfillRequest(id, int64(status)).
+		WithResponseHeaders(headers).
+		WithBody(base64.StdEncoding.EncodeToString(body)).
+		Do(ctx)
+}
+
+// matchesAny reports whether a request matches one of the patterns the way Chrome's Fetch domain
+// matches them.
+func matchesAny(patterns []*fetch.RequestPattern, url string, t network.ResourceType) bool {
+	for _, p := range patterns {
+		if (p.ResourceType == "" || p.ResourceType == t) && wildcardMatch(p.URLPattern, url) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatch matches s against pattern, where '*' matches any sequence, '?' one character and
+// a backslash escapes the next character.
+func wildcardMatch(pattern, s string) bool {
+	if pattern == "" {
+		return true // Fetch treats an empty pattern as "*"
+	}
+	var p, i int
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case p < len(pattern) && pattern[p] == '\\' && p+1 < len(pattern) && pattern[p+1] == s[i]:
+			p += 2
+			i++
+		case p < len(pattern) && pattern[p] != '\\' && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+func requestHeader(h network.Headers) http.Header {
+	out := http.Header{}
+	for k, v := range h {
+		if s, ok := v.(string); ok {
+			out.Set(k, s)
+		}
+	}
+	return out
+}
+
+// splitList splits a comma-separated parameter, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/domain"
+	"pdf-renderer/internal/infra/assetcache"
+)
+
+func TestExtractResourcePolicy(t *testing.T) {
+	cfg := testPDFCfg()
+	cfg.Render.BlockResourceTypes = []string{"media"}
+	cfg.Render.BlockURLPatterns = []string{"*://cdn.example/*"}
+	cfg.Render.BlockTrackers = true
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		p, err := extractResourcePolicy(c, cfg)
+		if err != nil {
+			return err
+		}
+		return c.JSON(p)
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+		want   domain.ResourcePolicy
+	}{
+		{"config defaults", "/", fiber.StatusOK, domain.ResourcePolicy{
+			BlockTypes: []string{"media"}, BlockURLs: []string{"*://cdn.example/*"}, BlockTrackers: true,
+		}},
+		{"request adds", "/?javascript=false&block=Image,+font,image&block_urls=*.png,*://x.example/*", fiber.StatusOK, domain.ResourcePolicy{
+			DisableJavaScript: true,
+			BlockTypes:        []string{"font", "image", "media"},
+			BlockURLs:         []string{"*.png", "*://cdn.example/*", "*://x.example/*"},
+			BlockTrackers:     true,
+		}},
+		{"invalid javascript", "/?javascript=maybe", fiber.StatusBadRequest, domain.ResourcePolicy{}},
+		{"unknown type", "/?block=document", fiber.StatusBadRequest, domain.ResourcePolicy{}},
+		{"too many patterns", "/?block_urls=" + strings.Repeat("a,", maxBlockURLPatterns+1), fiber.StatusBadRequest, domain.ResourcePolicy{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tc.target, nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, resp.StatusCode)
+			}
+			if tc.status != fiber.StatusOK {
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			want, _ := json.Marshal(tc.want)
+			if string(body) != string(want) {
+				t.Fatalf("expected %s, got %s", want, body)
+			}
+		})
+	}
+}
+
+func TestComputePDFCacheKey_Policy(t *testing.T) {
+	base := PDFRequestParams{HTML: "<html>x</html>", Format: "A4", Orientation: "portrait", Margin: 0.4}
+	withPolicy := base
+	withPolicy.Policy = domain.ResourcePolicy{BlockTypes: []string{"image"}}
+
+	if computePDFCacheKey(&base) == computePDFCacheKey(&withPolicy) {
+		t.Fatalf("expected the resource policy to change the cache key")
+	}
+	if policyCacheKey(domain.ResourcePolicy{}) != "" {
+		t.Fatalf("expected an empty policy to keep existing cache keys")
+	}
+}
+
+func TestFetchPatterns(t *testing.T) {
+	if got := fetchPatterns(domain.ResourcePolicy{}); len(got) != 0 {
+		t.Fatalf("expected no interception for the default policy, got %d patterns", len(got))
+	}
+
+	got := fetchPatterns(domain.ResourcePolicy{DisableJavaScript: true, BlockTypes: []string{"font"}, BlockURLs: []string{"*.gif"}})
+	want := []fetch.RequestPattern{
+		{URLPattern: "*", ResourceType: network.ResourceTypeFont},
+		{URLPattern: "*", ResourceType: network.ResourceTypeScript},
+		{URLPattern: "*.gif"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d patterns, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Fatalf("pattern %d: expected %+v, got %+v", i, want[i], *got[i])
+		}
+	}
+
+	trackers := fetchPatterns(domain.ResourcePolicy{BlockTrackers: true})
+	if len(trackers) != 2*len(trackerDomains) || trackers[1].URLPattern != "*://*.google-analytics.com/*" {
+		t.Fatalf("unexpected tracker patterns %+v", trackers[:2])
+	}
+}
+
+func TestRenderDiagnostics_PolicyBlocked(t *testing.T) {
+	_, d := withRenderDiagnostics(t.Context())
+	d.handleEvent(&network.EventRequestWillBeSent{RequestID: "1", Request: &network.Request{URL: "https://www.google-analytics.com/analytics.js"}})
+	d.handleEvent(&network.EventLoadingFailed{RequestID: "1", ErrorText: blockedByClientError})
+
+	r := d.snapshot()
+	if len(r.FailedRequests) != 0 || len(r.Blocked) != 1 || r.Blocked[0].Reason != "policy" {
+		t.Fatalf("expected a policy block, got %+v", r)
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	patterns := fetchPatterns(domain.ResourcePolicy{BlockTypes: []string{"font"}, BlockURLs: []string{"*.gif", `*/a\?b`}, BlockTrackers: true})
+
+	tests := []struct {
+		url  string
+		typ  network.ResourceType
+		want bool
+	}{
+		{"https://fonts.gstatic.com/x.woff2", network.ResourceTypeFont, true},
+		{"https://fonts.gstatic.com/x.woff2", network.ResourceTypeStylesheet, false},
+		{"https://example.com/spacer.gif", network.ResourceTypeImage, true},
+		{"https://example.com/spacer.gifv", network.ResourceTypeImage, false},
+		{"https://example.com/a?b", network.ResourceTypeXHR, true},
+		{"https://example.com/axb", network.ResourceTypeXHR, false},
+		{"https://www.google-analytics.com/analytics.js", network.ResourceTypeScript, true},
+		{"https://google-analytics.com/collect", network.ResourceTypePing, true},
+		{"https://notgoogle-analytics.com/x.js", network.ResourceTypeScript, false},
+	}
+	for _, tc := range tests {
+		if got := matchesAny(patterns, tc.url, tc.typ); got != tc.want {
+			t.Errorf("matchesAny(%s, %s) = %v, want %v", tc.url, tc.typ, got, tc.want)
+		}
+	}
+}
+
+func TestAssetPatterns(t *testing.T) {
+	if got := assetPatterns(nil); got != nil {
+		t.Fatalf("expected no patterns without an asset cache, got %+v", got)
+	}
+	online, err := assetcache.New(assetcache.Options{Dir: t.TempDir(), MaxBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := assetPatterns(online); len(got) != len(assetTypes) || got[0].ResourceType != network.ResourceTypeStylesheet {
+		t.Fatalf("unexpected online patterns %+v", got)
+	}
+	offline, err := assetcache.New(assetcache.Options{Dir: t.TempDir(), MaxBytes: 1 << 20, Offline: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := assetPatterns(offline); len(got) != 1 || got[0].URLPattern != "*" || got[0].ResourceType != "" {
+		t.Fatalf("expected every request to be intercepted offline, got %+v", got)
+	}
+
+	main, iframe := &fetch.EventRequestPaused{FrameID: "MAIN"}, &fetch.EventRequestPaused{FrameID: "CHILD"}
+	if !loadDocument(online, iframe, "MAIN") {
+		t.Fatalf("expected sub-frame documents to load online")
+	}
+	if !loadDocument(offline, main, "MAIN") || loadDocument(offline, iframe, "MAIN") {
+		t.Fatalf("expected only the top-level document to load offline")
+	}
+}
+
+func TestNewAssetCache_UsesLaunchProxy(t *testing.T) {
+	var proxied atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(r.URL.String()) // proxies receive the absolute URL
+		_, _ = w.Write([]byte("body{}"))
+	}))
+	defer proxy.Close()
+
+	cfg := testPDFCfg()
+	cfg.AssetCache.Enabled = true
+	cfg.AssetCache.Dir = t.TempDir()
+	cfg.Chrome.Launch.ProxyServer = proxy.URL
+	assets, err := newAssetCache(cfg)
+	if err != nil || assets == nil {
+		t.Fatalf("newAssetCache: %v", err)
+	}
+	e, _, err := assets.Get(t.Context(), "http://cdn.example/app.css", nil)
+	if err != nil || string(e.Body) != "body{}" {
+		t.Fatalf("expected the asset through the proxy, got %v", err)
+	}
+	if got, _ := proxied.Load().(string); got != "http://cdn.example/app.css" {
+		t.Fatalf("expected the proxy to receive the asset request, got %q", got)
+	}
+}
+
+func TestNewAssetCache_OfflineFailsClosed(t *testing.T) {
+	// A directory below a regular file cannot be created.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testPDFCfg()
+	cfg.AssetCache.Enabled = true
+	cfg.AssetCache.Dir = filepath.Join(file, "assets")
+
+	if assets, err := newAssetCache(cfg); assets != nil || err != nil {
+		t.Fatalf("expected an unusable cache to be skipped online, got %v %v", assets, err)
+	}
+
+	cfg.AssetCache.Offline = true
+	if _, err := newAssetCache(cfg); err == nil {
+		t.Fatalf("expected an error for an unusable offline cache")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected NewPDFService to refuse an unusable offline cache")
+		}
+	}()
+	NewPDFService(cfg, nil)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// renderTimings collects how long each phase of one request took (validation, cache access,
+// queueing, navigation, each render-ready step, printing). It travels in the request context so
+// the renderers can record phases; all methods are no-ops on a nil receiver.
+type renderTimings struct {
+	mu           sync.Mutex
+	phases       []phaseTiming // in order of first occurrence
+	readyTimeout string        // render-ready step that ran out of time, if any
+}
+
+type phaseTiming struct {
+	name string
+	dur  time.Duration
+}
+
+type renderTimingsKey struct{}
+
+// withRenderTimings returns ctx carrying a new timings recorder.
+func withRenderTimings(ctx context.Context) (context.Context, *renderTimings) {
+	t := &renderTimings{}
+	return context.WithValue(ctx, renderTimingsKey{}, t), t
+}
+
+// timingsFrom returns the recorder carried by ctx, or nil.
+func timingsFrom(ctx context.Context) *renderTimings {
+	t, _ := ctx.Value(renderTimingsKey{}).(*renderTimings)
+	return t
+}
+
+// add records d for phase. Repeated phases (e.g. a render retried after a Chrome crash) add up.
+func (t *renderTimings) add(phase string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.phases {
+		if t.phases[i].name == phase {
+			t.phases[i].dur += d
+			return
+		}
+	}
+	t.phases = append(t.phases, phaseTiming{name: phase, dur: d})
+}
+
+// since records the time elapsed since start for phase.
+func (t *renderTimings) since(phase string, start time.Time) {
+	t.add(phase, time.Since(start))
+}
+
+// markReadyTimeout records that the render-ready step ran out of time.
+func (t *renderTimings) markReadyTimeout(step string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.readyTimeout == "" {
+		t.readyTimeout = step
+	}
+}
+
+// serverTiming formats the phases as a Server-Timing header value (durations in milliseconds).
+// The render-ready step that timed out carries desc="timeout".
+func (t *renderTimings) serverTiming() string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parts := make([]string, 0, len(t.phases))
+	for _, p := range t.phases {
+		part := p.name + ";dur=" + strconv.FormatFloat(millis(p.dur), 'f', -1, 64)
+		if t.readyTimeout != "" && p.name == readyPhaseName(t.readyTimeout) {
+			part += `;desc="timeout"`
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// logFields returns the phases (in milliseconds) and the timed-out render-ready step as
+// key/value pairs for the logger.
+func (t *renderTimings) logFields() []any {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phases := make(map[string]float64, len(t.phases))
+	for _, p := range t.phases {
+		phases[p.name] = millis(p.dur)
+	}
+	fields := []any{"phases_ms", phases}
+	if t.readyTimeout != "" {
+		fields = append(fields, "ready_timeout", t.readyTimeout)
+	}
+	return fields
+}
+
+// readyPhaseName is the phase name of a render-ready step.
+func readyPhaseName(step string) string {
+	return "wait_ready." + step
+}
+
+// millis converts d to milliseconds, rounded to 0.1 ms.
+func millis(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*10) / 10
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"pdf-renderer/internal/infra/fake"
+)
+
+func TestRenderTimings_ServerTiming(t *testing.T) {
+	ctx, timings := withRenderTimings(context.Background())
+	if timingsFrom(ctx) != timings {
+		t.Fatalf("expected recorder in context")
+	}
+
+	timings.add("acquire", 2*time.Millisecond)
+	timings.add("navigate", 120*time.Millisecond+340*time.Microsecond)
+	timings.add(readyPhaseName("fonts"), 15*time.Second)
+	timings.add("acquire", time.Millisecond) // retried render
+	timings.markReadyTimeout("fonts")
+	timings.markReadyTimeout("images") // only the first timeout is kept
+
+	want := `acquire;dur=3, navigate;dur=120.3, wait_ready.fonts;dur=15000;desc="timeout"`
+	if got := timings.serverTiming(); got != want {
+		t.Fatalf("unexpected Server-Timing\n got: %s\nwant: %s", got, want)
+	}
+
+	fields := timings.logFields()
+	if len(fields) != 4 || fields[0] != "phases_ms" || fields[2] != "ready_timeout" || fields[3] != "fonts" {
+		t.Fatalf("unexpected log fields %v", fields)
+	}
+	if phases := fields[1].(map[string]float64); phases["navigate"] != 120.3 {
+		t.Fatalf("unexpected phases %v", phases)
+	}
+}
+
+func TestRenderTimings_NilRecorderIsNoop(t *testing.T) {
+	timings := timingsFrom(context.Background())
+	timings.add("render", time.Second)
+	timings.markReadyTimeout("fonts")
+	if timings.serverTiming() != "" || timings.logFields() != nil {
+		t.Fatalf("expected nil recorder to report nothing")
+	}
+}
+
+func TestHandleConversion_ServerTimingHeader(t *testing.T) {
+	_, app := newFakeService(t, fake.NewRenderer())
+
+	req := httptest.NewRequest("POST", "/pdf", strings.NewReader("html=<html><body>hello world</body></html>"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	header := resp.Header.Get("Server-Timing")
+	for _, phase := range []string{"validate;dur=", "render;dur=", "total;dur="} {
+		if !strings.Contains(header, phase) {
+			t.Fatalf("expected %q in Server-Timing %q", phase, header)
+		}
+	}
+
+	// Rejected requests still report how long validation took.
+	resp, err = app.Test(httptest.NewRequest("GET", "/pdf", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+	if header := resp.Header.Get("Server-Timing"); !strings.HasPrefix(header, "validate;dur=") {
+		t.Fatalf("expected validation timing on error, got %q", header)
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"pdf-renderer/internal/config"
 	"pdf-renderer/internal/infra/logging"
+	"pdf-renderer/internal/infra/tracing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -15,7 +16,11 @@ import (
 //
 // Auth and rate limiting are intentionally NOT handled here anymore.
 // They are enforced at the gateway (Envoy) via an external auth service.
-func Register(app *fiber.App, cfg config.Config) {
+//
+// /ops/health reports liveness (the process is serving requests); /ops/ready reports readiness
+// using the ready probe (nil = always ready), so the gateway stops routing to an instance whose
+// renderer is broken.
+func Register(app *fiber.App, cfg config.Config, ready func(*fiber.Ctx) bool) {
 	_ = cfg // kept for forward-compat; middleware might use config later.
 
 	app.Use(cors.New())
@@ -28,15 +33,19 @@ func Register(app *fiber.App, cfg config.Config) {
 
 	app.Use(healthcheck.New(healthcheck.Config{
 		LivenessEndpoint:  "/ops/health",
-		ReadinessEndpoint: "/ops/health",
+		ReadinessEndpoint: "/ops/ready",
+		ReadinessProbe:    ready,
 	}))
 
+	// Registered after the health checks so gateway probes do not produce traces.
+	app.Use(tracing.Middleware())
+
 	app.Use(func(c *fiber.Ctx) error {
 		requestID := c.Get("X-Request-ID")
 		if requestID == "" {
 			requestID = c.GetRespHeader("X-Request-ID")
 		}
-		logging.Info("Incoming request", "method", c.Method(), "path", c.Path(), "request_id", requestID)
+		logging.Info("Incoming request", "method", c.Method(), "path", c.Path(), "request_id", requestID, "trace_id", tracing.TraceID(c.UserContext()))
 		return c.Next()
 	})
 }
@@ -11,7 +11,7 @@ import (
 
 func TestRegister_AddsHealthAndRequestID(t *testing.T) {
 	app := fiber.New()
-	Register(app, config.Config{})
+	Register(app, config.Config{}, nil)
 	app.Get("/ping", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
 
 	healthReq, _ := http.NewRequest(http.MethodGet, "/ops/health", nil)
@@ -32,3 +32,26 @@ func TestRegister_AddsHealthAndRequestID(t *testing.T) {
 		t.Fatalf("expected X-Request-Id to be present")
 	}
 }
+
+func TestRegister_ReadinessUsesProbe(t *testing.T) {
+	ready := false
+	app := fiber.New()
+	Register(app, config.Config{}, func(*fiber.Ctx) bool { return ready })
+
+	check := func(path string, want int) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", path, err)
+		}
+		if resp.StatusCode != want {
+			t.Fatalf("expected %s to return %d, got %d", path, want, resp.StatusCode)
+		}
+	}
+
+	check("/ops/health", fiber.StatusOK)
+	check("/ops/ready", fiber.StatusServiceUnavailable)
+	ready = true
+	check("/ops/ready", fiber.StatusOK)
+}
@@ -5,6 +5,7 @@ import (
 	"pdf-renderer/internal/http/handlers"
 	"pdf-renderer/internal/http/middleware"
 	"pdf-renderer/internal/infra/logging"
+	"pdf-renderer/internal/infra/metrics"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
@@ -42,8 +43,12 @@ func New(deps Deps) *fiber.App {
 		},
 	})
 
-	middleware.Register(app, cfg)
-	registerRoutes(app, cfg, deps.Redis)
+	// Create one shared service instance so /v0/pdf (GET+POST) share the same Chrome pool.
+	svc := handlers.NewPDFService(cfg, deps.Redis)
+	app.Hooks().OnShutdown(svc.Close)
+
+	middleware.Register(app, cfg, svc.Ready)
+	registerRoutes(app, svc)
 
 	// Ensure all responses, including 404s, return JSON.
 	app.Use(func(c *fiber.Ctx) error {
@@ -53,13 +58,13 @@ func New(deps Deps) *fiber.App {
 	return app
 }
 
-func registerRoutes(app *fiber.App, cfg config.Config, redis *redis.Client) {
+func registerRoutes(app *fiber.App, svc *handlers.PDFService) {
 	v0 := app.Group("/v0")
 
-	// Create one shared service instance so /v0/pdf (GET+POST) share the same Chrome pool.
-	svc := handlers.NewPDFService(cfg, redis)
-
 	v0.Post("/pdf", svc.HandleConversion)
 	v0.Get("/pdf", svc.HandleURLConversion)
 	v0.Get("/chrome/stats", svc.HandleChromeStats)
+
+	app.Get("/ops/metrics", metrics.Handler())
+	app.Get("/ops/fonts", svc.HandleFonts)
 }
@@ -0,0 +1,528 @@
+// Package assetcache is a size-bounded disk cache for the sub-resources pages pull from CDNs
+// (stylesheets, scripts, fonts, images). Entries are keyed by URL and kept as long as their
+// Cache-Control allows; a seed directory can pin assets, and in offline mode nothing is fetched.
+package assetcache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrOffline is returned in offline mode for URLs that are neither cached nor seeded.
+	ErrOffline = errors.New("asset not cached (offline mode)")
+	// ErrUncacheable is returned for responses the cache does not keep (no-store, too large);
+	// the caller should let the browser load the URL itself.
+	ErrUncacheable = errors.New("asset not cacheable")
+)
+
+// Result describes how Get produced an entry; it is used as a metrics label.
+type Result string
+
+const (
+	ResultSeed        Result = "seed"        // served from the seed directory
+	ResultHit         Result = "hit"         // fresh cache entry
+	ResultRevalidated Result = "revalidated" // stale entry confirmed by the origin (304)
+	ResultStale       Result = "stale"       // stale entry served because the origin was unreachable or offline mode is on
+	ResultMiss        Result = "miss"        // fetched from the origin
+	ResultOfflineMiss Result = "offline_miss"
+	ResultBypass      Result = "bypass" // not cacheable; the browser loads it
+)
+
+// storedHeaders are the response headers kept with an entry and replayed to the browser.
+var storedHeaders = []string{
+	"Content-Type",
+	"Content-Language",
+	"Cache-Control",
+	"ETag",
+	"Last-Modified",
+	"Access-Control-Allow-Origin",
+	"Timing-Allow-Origin",
+}
+
+// forwardedHeaders are the browser request headers sent along when fetching an asset.
+var forwardedHeaders = []string{"User-Agent", "Accept", "Accept-Language"}
+
+// Options configures a Cache.
+type Options struct {
+	Dir          string        // cache directory (created if missing)
+	MaxBytes     int64         // total size of cached bodies; least recently used entries are evicted beyond it
+	SeedDir      string        // optional directory laid out as <host>/<path>, served as https://<host>/<path>
+	Offline      bool          // never fetch; uncached URLs fail with ErrOffline
+	DefaultTTL   time.Duration // freshness for responses without Cache-Control max-age or Expires
+	FetchTimeout time.Duration // timeout per origin fetch
+	Client       *http.Client  // optional; defaults to a client with FetchTimeout
+}
+
+// Entry is a cached response.
+type Entry struct {
+	URL    string
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// meta is the on-disk description of an entry; the body is stored next to it.
+type meta struct {
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Size     int64       `json:"size"`
+	Expires  time.Time   `json:"expires"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+type item struct {
+	key  string
+	meta meta
+}
+
+// Cache is safe for concurrent use.
+type Cache struct {
+	opts   Options
+	client *http.Client
+	seeds  map[string]string // key → file in SeedDir
+
+	mu    sync.Mutex
+	lru   *list.List // of *item, most recently used first
+	index map[string]*list.Element
+	size  int64
+
+	now func() time.Time
+}
+
+// New opens the cache directory, indexes existing entries and the seed directory.
+func New(opts Options) (*Cache, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("asset cache directory not configured")
+	}
+	if opts.MaxBytes <= 0 {
+		return nil, errors.New("asset cache size must be positive")
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create asset cache directory: %w", err)
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.FetchTimeout}
+	}
+	c := &Cache{
+		opts:   opts,
+		client: client,
+		seeds:  make(map[string]string),
+		lru:    list.New(),
+		index:  make(map[string]*list.Element),
+		now:    time.Now,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	if opts.SeedDir != "" {
+		if err := c.seed(opts.SeedDir); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Stats reports the number of cached entries, their total size and the number of seeded files.
+func (c *Cache) Stats() (entries int, bytes int64, seeded int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len(), c.size, len(c.seeds)
+}
+
+// Offline reports whether the cache runs in offline mode.
+func (c *Cache) Offline() bool { return c.opts.Offline }
+
+// Get returns the response for rawURL from the seed directory, the cache or the origin.
+// reqHeader carries the browser's request headers; a few of them are forwarded to the origin.
+func (c *Cache) Get(ctx context.Context, rawURL string, reqHeader http.Header) (*Entry, Result, error) {
+	key, seedKey, err := cacheKey(rawURL)
+	if err != nil {
+		return nil, ResultBypass, ErrUncacheable
+	}
+	if path, ok := c.seeds[seedKey]; ok {
+		e, err := seededEntry(rawURL, path)
+		if err == nil {
+			return e, ResultSeed, nil
+		}
+	}
+
+	m, cached := c.lookup(key)
+	if cached && c.now().Before(m.Expires) {
+		if e, err := c.readEntry(key, m); err == nil {
+			return e, ResultHit, nil
+		}
+		cached = false
+	}
+	if c.opts.Offline {
+		if cached {
+			if e, err := c.readEntry(key, m); err == nil {
+				return e, ResultStale, nil
+			}
+		}
+		return nil, ResultOfflineMiss, ErrOffline
+	}
+
+	var stale *meta
+	if cached {
+		stale = &m
+	}
+	return c.fetch(ctx, key, rawURL, reqHeader, stale)
+}
+
+// fetch loads rawURL from the origin, revalidating stale when it is set, and stores the response.
+func (c *Cache) fetch(ctx context.Context, key, rawURL string, reqHeader http.Header, stale *meta) (*Entry, Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, ResultBypass, ErrUncacheable
+	}
+	for _, h := range forwardedHeaders {
+		if v := reqHeader.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+	if stale != nil {
+		if v := stale.Header.Get("ETag"); v != "" {
+			req.Header.Set("If-None-Match", v)
+		}
+		if v := stale.Header.Get("Last-Modified"); v != "" {
+			req.Header.Set("If-Modified-Since", v)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if stale != nil {
+			if e, rerr := c.readEntry(key, *stale); rerr == nil {
+				return e, ResultStale, nil
+			}
+		}
+		return nil, ResultBypass, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotModified && stale != nil {
+		m := *stale
+		if exp, ok := c.expires(resp.Header); ok {
+			m.Expires = exp
+		}
+		e, err := c.readEntry(key, m)
+		if err != nil {
+			return nil, ResultBypass, ErrUncacheable
+		}
+		c.touch(key, m)
+		return e, ResultRevalidated, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Errors and redirects are left to the browser.
+		return nil, ResultBypass, ErrUncacheable
+	}
+
+	limit := c.maxEntryBytes()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, ResultBypass, err
+	}
+	if int64(len(body)) > limit {
+		return nil, ResultBypass, ErrUncacheable
+	}
+
+	e := &Entry{URL: rawURL, Status: resp.StatusCode, Header: filterHeader(resp.Header), Body: body}
+	if exp, ok := c.expires(resp.Header); ok {
+		c.put(key, e, exp)
+	}
+	return e, ResultMiss, nil
+}
+
+// expires computes the freshness deadline from Cache-Control and Expires. The cache is shared by
+// every tenant, so it follows the rules for shared caches (RFC 9111): private responses are not
+// stored, s-maxage wins over max-age, and responses that vary on anything but Accept-Encoding are
+// not stored as the cache keeps one variant per URL. ok is false for responses that must not be stored.
+func (c *Cache) expires(h http.Header) (time.Time, bool) {
+	now := c.now()
+	for _, field := range h.Values("Vary") {
+		for _, name := range strings.Split(field, ",") {
+			if name = strings.TrimSpace(name); name != "" && !strings.EqualFold(name, "Accept-Encoding") {
+				return time.Time{}, false
+			}
+		}
+	}
+
+	maxAge, sMaxAge := int64(-1), int64(-1)
+	noCache := false
+	for _, directive := range strings.Split(strings.ToLower(strings.Join(h.Values("Cache-Control"), ",")), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch name {
+		case "no-store", "private":
+			return time.Time{}, false
+		case "no-cache":
+			noCache = true
+		case "max-age", "s-maxage":
+			var secs int64
+			if _, err := fmt.Sscan(strings.Trim(value, `"`), &secs); err != nil {
+				continue
+			}
+			if name == "s-maxage" {
+				sMaxAge = secs
+			} else {
+				maxAge = secs
+			}
+		}
+	}
+	switch {
+	case noCache:
+		return now, true // store, but revalidate on every use
+	case sMaxAge >= 0:
+		return now.Add(time.Duration(sMaxAge) * time.Second), true
+	case maxAge >= 0:
+		return now.Add(time.Duration(maxAge) * time.Second), true
+	}
+	if v := h.Get("Expires"); v != "" {
+		t, err := http.ParseTime(v)
+		if err != nil {
+			return now, true // invalid Expires means already expired
+		}
+		return t, true
+	}
+	return now.Add(c.opts.DefaultTTL), true
+}
+
+// maxEntryBytes bounds a single entry to a quarter of the cache so one asset cannot flush it.
+func (c *Cache) maxEntryBytes() int64 {
+	return max(c.opts.MaxBytes/4, 1)
+}
+
+func (c *Cache) lookup(key string) (meta, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.index[key]
+	if !ok {
+		return meta{}, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*item).meta, true
+}
+
+// touch updates the metadata of a revalidated entry.
+func (c *Cache) touch(key string, m meta) {
+	if err := writeFileAtomic(c.metaPath(key), mustJSON(m)); err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.index[key]; ok {
+		el.Value.(*item).meta = m
+	}
+}
+
+// put stores e and evicts least recently used entries beyond MaxBytes.
+func (c *Cache) put(key string, e *Entry, expires time.Time) {
+	m := meta{
+		URL:      e.URL,
+		Status:   e.Status,
+		Header:   e.Header,
+		Size:     int64(len(e.Body)),
+		Expires:  expires,
+		StoredAt: c.now(),
+	}
+	if writeFileAtomic(c.bodyPath(key), e.Body) != nil || writeFileAtomic(c.metaPath(key), mustJSON(m)) != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.index[key]; ok {
+		c.size -= el.Value.(*item).meta.Size
+		el.Value.(*item).meta = m
+		c.lru.MoveToFront(el)
+	} else {
+		c.index[key] = c.lru.PushFront(&item{key: key, meta: m})
+	}
+	c.size += m.Size
+	c.evictLocked()
+}
+
+func (c *Cache) evictLocked() {
+	for c.size > c.opts.MaxBytes && c.lru.Len() > 0 {
+		el := c.lru.Back()
+		it := el.Value.(*item)
+		c.lru.Remove(el)
+		delete(c.index, it.key)
+		c.size -= it.meta.Size
+		_ = os.Remove(c.bodyPath(it.key))
+		_ = os.Remove(c.metaPath(it.key))
+	}
+}
+
+func (c *Cache) readEntry(key string, m meta) (*Entry, error) {
+	body, err := os.ReadFile(c.bodyPath(key))
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{URL: m.URL, Status: m.Status, Header: m.Header.Clone(), Body: body}, nil
+}
+
+// load indexes the entries left in the cache directory by a previous run, oldest first, and
+// removes incomplete ones.
+func (c *Cache) load() error {
+	files, err := filepath.Glob(filepath.Join(c.opts.Dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var items []*item
+	for _, f := range files {
+		key := strings.TrimSuffix(filepath.Base(f), ".json")
+		var m meta
+		data, err := os.ReadFile(f)
+		if err == nil {
+			err = json.Unmarshal(data, &m)
+		}
+		if err == nil {
+			var fi os.FileInfo
+			if fi, err = os.Stat(c.bodyPath(key)); err == nil && fi.Size() != m.Size {
+				err = errors.New("size mismatch")
+			}
+		}
+		if err != nil {
+			_ = os.Remove(f)
+			_ = os.Remove(c.bodyPath(key))
+			continue
+		}
+		items = append(items, &item{key: key, meta: m})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].meta.StoredAt.Before(items[j].meta.StoredAt) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, it := range items {
+		c.index[it.key] = c.lru.PushFront(it)
+		c.size += it.meta.Size
+	}
+	c.evictLocked()
+
+	// Bodies without metadata and temporary files are left over from interrupted writes.
+	leftovers, _ := filepath.Glob(filepath.Join(c.opts.Dir, ".tmp-*"))
+	bodies, _ := filepath.Glob(filepath.Join(c.opts.Dir, "*.body"))
+	for _, f := range bodies {
+		if _, ok := c.index[strings.TrimSuffix(filepath.Base(f), ".body")]; !ok {
+			leftovers = append(leftovers, f)
+		}
+	}
+	for _, f := range leftovers {
+		_ = os.Remove(f)
+	}
+	return nil
+}
+
+// seed indexes the files below dir; the first path element is the host.
+func (c *Cache) seed(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("cannot read asset seed directory: %w", err)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		host, p, ok := strings.Cut(filepath.ToSlash(rel), "/")
+		if !ok {
+			return nil // files directly in the seed directory have no host
+		}
+		c.seeds[strings.ToLower(host)+"/"+p] = path
+		return nil
+	})
+}
+
+func (c *Cache) bodyPath(key string) string { return filepath.Join(c.opts.Dir, key+".body") }
+func (c *Cache) metaPath(key string) string { return filepath.Join(c.opts.Dir, key+".json") }
+
+// cacheKey returns the file name for rawURL and the key of its seed file. The scheme is not
+// part of either: the same asset is cached once for http and https.
+func cacheKey(rawURL string) (key, seedKey string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", ErrUncacheable
+	}
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	seedKey = host + "/" + strings.TrimPrefix(u.Path, "/")
+	full := seedKey
+	if u.RawQuery != "" {
+		full += "?" + u.RawQuery
+	}
+	sum := sha256.Sum256([]byte(full))
+	return hex.EncodeToString(sum[:]), seedKey, nil
+}
+
+func seededEntry(rawURL, path string) (*Entry, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	h := http.Header{}
+	h.Set("Content-Type", contentType(path, body))
+	h.Set("Access-Control-Allow-Origin", "*")
+	return &Entry{URL: rawURL, Status: http.StatusOK, Header: h, Body: body}, nil
+}
+
+// contentType guesses the type of a seeded file from its extension, else from its content.
+func contentType(path string, body []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	return http.DetectContentType(body)
+}
+
+func filterHeader(h http.Header) http.Header {
+	out := http.Header{}
+	for _, name := range storedHeaders {
+		for _, v := range h.Values(name) {
+			out.Add(name, v)
+		}
+	}
+	return out
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func mustJSON(v any) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
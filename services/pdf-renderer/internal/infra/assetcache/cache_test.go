@@ -0,0 +1,239 @@
+package assetcache
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, opts Options) *Cache {
+	t.Helper()
+	if opts.Dir == "" {
+		opts.Dir = t.TempDir()
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = 1 << 20
+	}
+	c, err := New(opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestGet_CachesByCacheControl(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/app.css":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			w.Header().Set("Content-Type", "text/css")
+			w.Header().Set("Set-Cookie", "id=1")
+			_, _ = w.Write([]byte("body{}"))
+		case "/nostore.js":
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte("1"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestCache(t, Options{})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	e, res, err := c.Get(t.Context(), srv.URL+"/app.css", nil)
+	if err != nil || res != ResultMiss || string(e.Body) != "body{}" {
+		t.Fatalf("first get: %v %v %+v", err, res, e)
+	}
+	if e.Header.Get("Set-Cookie") != "" || e.Header.Get("Content-Type") != "text/css" {
+		t.Fatalf("unexpected stored headers %v", e.Header)
+	}
+	if _, res, _ = c.Get(t.Context(), srv.URL+"/app.css", nil); res != ResultHit {
+		t.Fatalf("expected hit, got %v", res)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("expected one origin request, got %d", hits.Load())
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, res, _ = c.Get(t.Context(), srv.URL+"/app.css", nil); res != ResultMiss {
+		t.Fatalf("expected refetch after max-age, got %v", res)
+	}
+
+	for range 2 {
+		if _, res, _ = c.Get(t.Context(), srv.URL+"/nostore.js", nil); res != ResultMiss {
+			t.Fatalf("expected no-store responses to be fetched every time, got %v", res)
+		}
+	}
+	if _, _, err = c.Get(t.Context(), srv.URL+"/missing.png", nil); !errors.Is(err, ErrUncacheable) {
+		t.Fatalf("expected 404 to bypass the cache, got %v", err)
+	}
+}
+
+func TestGet_SharedCacheRules(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/private.css":
+			w.Header().Set("Cache-Control", "private, max-age=600")
+		case "/s-maxage.js":
+			w.Header().Set("Cache-Control", "max-age=600, s-maxage=30")
+		case "/vary-cookie.png":
+			w.Header().Set("Cache-Control", "max-age=600")
+			w.Header().Set("Vary", "Accept-Encoding, Cookie")
+		case "/vary-encoding.png":
+			w.Header().Set("Cache-Control", "max-age=600")
+			w.Header().Set("Vary", "accept-encoding")
+		}
+		_, _ = w.Write([]byte("asset"))
+	}))
+	defer srv.Close()
+
+	c := newTestCache(t, Options{})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	get := func(path string) Result {
+		t.Helper()
+		e, res, err := c.Get(t.Context(), srv.URL+path, nil)
+		if err != nil || string(e.Body) != "asset" {
+			t.Fatalf("get %s: %v %+v", path, err, e)
+		}
+		return res
+	}
+
+	for _, path := range []string{"/private.css", "/vary-cookie.png"} {
+		get(path)
+		if res := get(path); res != ResultMiss {
+			t.Fatalf("%s: expected the response not to be stored, got %v", path, res)
+		}
+	}
+	get("/vary-encoding.png")
+	if res := get("/vary-encoding.png"); res != ResultHit {
+		t.Fatalf("expected Vary: Accept-Encoding to be cached, got %v", res)
+	}
+
+	get("/s-maxage.js")
+	now = now.Add(time.Minute)
+	if res := get("/s-maxage.js"); res != ResultMiss {
+		t.Fatalf("expected s-maxage to win over max-age, got %v", res)
+	}
+}
+
+func TestGet_RevalidatesAndServesStale(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = w.Write([]byte("font"))
+	}))
+	defer srv.Close()
+
+	c := newTestCache(t, Options{})
+	if _, res, _ := c.Get(t.Context(), srv.URL+"/f.woff2", nil); res != ResultMiss {
+		t.Fatalf("expected miss, got %v", res)
+	}
+	e, res, err := c.Get(t.Context(), srv.URL+"/f.woff2", nil)
+	if err != nil || res != ResultRevalidated || string(e.Body) != "font" {
+		t.Fatalf("expected revalidated entry, got %v %v", res, err)
+	}
+
+	srv.Close()
+	if e, res, _ = c.Get(t.Context(), srv.URL+"/f.woff2", nil); res != ResultStale || string(e.Body) != "font" {
+		t.Fatalf("expected stale entry while the origin is down, got %v", res)
+	}
+}
+
+func TestGet_EvictsLeastRecentlyUsed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 20)))
+	}))
+	defer srv.Close()
+
+	c := newTestCache(t, Options{MaxBytes: 80, DefaultTTL: time.Hour})
+	for _, p := range []string{"/a", "/b", "/c", "/d"} {
+		if _, _, err := c.Get(t.Context(), srv.URL+p, nil); err != nil {
+			t.Fatalf("get %s: %v", p, err)
+		}
+	}
+	// /a is used again, so /b is the least recently used when /e arrives.
+	if _, res, _ := c.Get(t.Context(), srv.URL+"/a", nil); res != ResultHit {
+		t.Fatalf("expected hit, got %v", res)
+	}
+	if _, _, err := c.Get(t.Context(), srv.URL+"/e", nil); err != nil {
+		t.Fatalf("get /e: %v", err)
+	}
+	if entries, size, _ := c.Stats(); entries != 4 || size != 80 {
+		t.Fatalf("expected 4 entries / 80 bytes, got %d / %d", entries, size)
+	}
+	if _, res, _ := c.Get(t.Context(), srv.URL+"/b", nil); res != ResultMiss {
+		t.Fatalf("expected /b to be evicted, got %v", res)
+	}
+}
+
+func TestNew_ReloadsEntriesAndOffline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=1")
+		_, _ = w.Write([]byte("js"))
+	}))
+	dir := t.TempDir()
+	c := newTestCache(t, Options{Dir: dir})
+	if _, _, err := c.Get(t.Context(), srv.URL+"/lib.js?v=2", nil); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	srv.Close()
+
+	c = newTestCache(t, Options{Dir: dir, Offline: true})
+	c.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if entries, _, _ := c.Stats(); entries != 1 {
+		t.Fatalf("expected the entry to be reloaded, got %d", entries)
+	}
+	if e, res, err := c.Get(t.Context(), srv.URL+"/lib.js?v=2", nil); err != nil || res != ResultStale || string(e.Body) != "js" {
+		t.Fatalf("expected expired entry to be served offline, got %v %v", res, err)
+	}
+	if _, res, err := c.Get(t.Context(), srv.URL+"/other.js", nil); !errors.Is(err, ErrOffline) || res != ResultOfflineMiss {
+		t.Fatalf("expected offline miss, got %v %v", res, err)
+	}
+}
+
+func TestGet_Seed(t *testing.T) {
+	seed := t.TempDir()
+	path := filepath.Join(seed, "cdn.example.com", "npm", "bootstrap.min.css")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(".btn{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestCache(t, Options{SeedDir: seed, Offline: true})
+	e, res, err := c.Get(t.Context(), "https://CDN.example.com/npm/bootstrap.min.css?v=5", nil)
+	if err != nil || res != ResultSeed || string(e.Body) != ".btn{}" {
+		t.Fatalf("expected seeded file, got %v %v", res, err)
+	}
+	if ct := e.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/css") {
+		t.Fatalf("expected text/css, got %q", ct)
+	}
+	if e.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("expected seeded assets to allow cross-origin use")
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Options{MaxBytes: 1}); err == nil {
+		t.Fatalf("expected an error without a directory")
+	}
+	if _, err := New(Options{Dir: t.TempDir()}); err == nil {
+		t.Fatalf("expected an error without a size")
+	}
+}
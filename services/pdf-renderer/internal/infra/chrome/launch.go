@@ -0,0 +1,152 @@
+package chrome
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+
+	"pdf-renderer/internal/config"
+	"pdf-renderer/internal/infra/logging"
+)
+
+// baseFlags are the launch flags every locally started Chromium gets, on top of
+// chromedp.DefaultExecAllocatorOptions. chrome.launch.extra_flags may override them.
+var baseFlags = []struct {
+	name  string
+	value any
+}{
+	// Avoid Vulkan/ANGLE issues in minimal container environments.
+	{"disable-gpu", true},
+	{"disable-gpu-compositing", true},
+	{"disable-features", "Vulkan,UseSkiaRenderer"},
+	{"use-gl", "swiftshader"},
+
+	// I still recommend shm_size in compose, but keep this as a safe default.
+	{"disable-dev-shm-usage", true},
+
+	// Reduce background noise.
+	{"disable-background-networking", true},
+	{"disable-background-timer-throttling", true},
+	{"disable-breakpad", true},
+	{"disable-client-side-phishing-detection", true},
+	{"disable-component-update", true},
+	{"disable-default-apps", true},
+	{"disable-domain-reliability", true},
+	{"disable-extensions", true},
+	{"disable-sync", true},
+}
+
+// ExecAllocatorOptions builds the options for launching a local Chromium with the given profile
+// directory, for pooled browsers and per-request ones alike.
+func ExecAllocatorOptions(cfg config.Config, profileDir string) ([]chromedp.ExecAllocatorOption, error) {
+	flags, err := launchFlags(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// chromedp.DefaultExecAllocatorOptions may be an array in some versions.
+	// Convert it to a slice before using variadic expansion.
+	opts := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
+
+	// Only override exec path when configured.
+	if cfg.PDF.ChromePath != "" {
+		opts = append(opts, chromedp.ExecPath(cfg.PDF.ChromePath))
+	}
+	opts = append(opts, chromedp.UserDataDir(profileDir))
+
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		opts = append(opts, chromedp.Flag(name, flags[name]))
+	}
+	return opts, nil
+}
+
+// ValidateLaunch checks the chrome.launch section. In remote mode the section has no effect,
+// which is logged when it is set.
+func ValidateLaunch(cfg config.Config) error {
+	if _, err := launchFlags(cfg); err != nil {
+		return err
+	}
+	l := cfg.Chrome.Launch
+	if IsRemote(cfg) && (len(l.ExtraFlags) > 0 || l.ProxyServer != "" || l.UserAgent != "" || l.AcceptLanguage != "" || l.WindowSize != "") {
+		logging.Warn("chrome.launch is ignored in remote mode; configure the remote Chromium instead")
+	}
+	return nil
+}
+
+// launchFlags returns the command-line flags (name without dashes → value) derived from cfg. A
+// false value removes a flag that chromedp would otherwise set.
+func launchFlags(cfg config.Config) (map[string]any, error) {
+	flags := make(map[string]any, len(baseFlags)+8)
+	for _, f := range baseFlags {
+		flags[f.name] = f.value
+	}
+	if cfg.PDF.ChromeNoSandbox {
+		flags["no-sandbox"] = true
+	}
+
+	launch := cfg.Chrome.Launch
+	if launch.ProxyServer != "" {
+		flags["proxy-server"] = launch.ProxyServer
+	}
+	if launch.ProxyBypassList != "" {
+		if launch.ProxyServer == "" {
+			return nil, fmt.Errorf("chrome.launch.proxy_bypass_list requires proxy_server")
+		}
+		flags["proxy-bypass-list"] = launch.ProxyBypassList
+	}
+	if launch.UserAgent != "" {
+		flags["user-agent"] = launch.UserAgent
+	}
+	if launch.AcceptLanguage != "" {
+		flags["accept-lang"] = launch.AcceptLanguage
+	}
+	if launch.WindowSize != "" {
+		w, h, err := parseWindowSize(launch.WindowSize)
+		if err != nil {
+			return nil, err
+		}
+		flags["window-size"] = fmt.Sprintf("%d,%d", w, h)
+	}
+
+	for _, raw := range launch.ExtraFlags {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(strings.TrimSpace(raw), "-"), "=")
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid chrome.launch.extra_flags entry %q", raw)
+		}
+		switch {
+		case !hasValue:
+			flags[name] = true
+		case value == "false":
+			flags[name] = false
+		default:
+			flags[name] = value
+		}
+	}
+	return flags, nil
+}
+
+// parseWindowSize parses "<width>x<height>" (or "<width>,<height>").
+func parseWindowSize(s string) (width, height int, err error) {
+	ws, hs, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok {
+		ws, hs, ok = strings.Cut(s, ",")
+	}
+	if ok {
+		width, err = strconv.Atoi(strings.TrimSpace(ws))
+		if err == nil {
+			height, err = strconv.Atoi(strings.TrimSpace(hs))
+		}
+	}
+	if !ok || err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid chrome.launch.window_size %q (expected <width>x<height>)", s)
+	}
+	return width, height, nil
+}
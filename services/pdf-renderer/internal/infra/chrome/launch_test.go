@@ -0,0 +1,89 @@
+package chrome
+
+import (
+	"testing"
+
+	"pdf-renderer/internal/config"
+)
+
+func TestLaunchFlags(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.PDF.ChromeNoSandbox = true
+	cfg.Chrome.Launch.ProxyServer = "http://proxy:3128"
+	cfg.Chrome.Launch.ProxyBypassList = "localhost;*.internal"
+	cfg.Chrome.Launch.UserAgent = "html2pdf/1.0"
+	cfg.Chrome.Launch.AcceptLanguage = "de-DE,de"
+	cfg.Chrome.Launch.WindowSize = "1280x1024"
+	cfg.Chrome.Launch.ExtraFlags = []string{"--lang=de", "--font-render-hinting=none", "--disable-gpu=false", "hide-scrollbars"}
+
+	flags, err := launchFlags(cfg)
+	if err != nil {
+		t.Fatalf("launchFlags: %v", err)
+	}
+	want := map[string]any{
+		"no-sandbox":            true,
+		"proxy-server":          "http://proxy:3128",
+		"proxy-bypass-list":     "localhost;*.internal",
+		"user-agent":            "html2pdf/1.0",
+		"accept-lang":           "de-DE,de",
+		"window-size":           "1280,1024",
+		"lang":                  "de",
+		"font-render-hinting":   "none",
+		"disable-gpu":           false,
+		"hide-scrollbars":       true,
+		"disable-dev-shm-usage": true,
+	}
+	for name, value := range want {
+		if flags[name] != value {
+			t.Errorf("flag %s = %v, want %v", name, flags[name], value)
+		}
+	}
+
+	if _, err := ExecAllocatorOptions(cfg, t.TempDir()); err != nil {
+		t.Fatalf("ExecAllocatorOptions: %v", err)
+	}
+}
+
+func TestLaunchFlags_Defaults(t *testing.T) {
+	flags, err := launchFlags(testConfig(1))
+	if err != nil {
+		t.Fatalf("launchFlags: %v", err)
+	}
+	for _, name := range []string{"no-sandbox", "proxy-server", "user-agent", "accept-lang", "window-size"} {
+		if _, ok := flags[name]; ok {
+			t.Errorf("unexpected default flag %s", name)
+		}
+	}
+	if flags["use-gl"] != "swiftshader" {
+		t.Errorf("expected the built-in software rendering flags, got %v", flags)
+	}
+}
+
+func TestValidateLaunch(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Config)
+	}{
+		{"window size without x", func(cfg *config.Config) { cfg.Chrome.Launch.WindowSize = "1280" }},
+		{"window size not a number", func(cfg *config.Config) { cfg.Chrome.Launch.WindowSize = "widex768" }},
+		{"zero window size", func(cfg *config.Config) { cfg.Chrome.Launch.WindowSize = "0x768" }},
+		{"bypass without proxy", func(cfg *config.Config) { cfg.Chrome.Launch.ProxyBypassList = "localhost" }},
+		{"empty extra flag", func(cfg *config.Config) { cfg.Chrome.Launch.ExtraFlags = []string{"--"} }},
+		{"extra flag with space", func(cfg *config.Config) { cfg.Chrome.Launch.ExtraFlags = []string{"--lang de"} }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig(1)
+			tc.modify(&cfg)
+			if err := ValidateLaunch(cfg); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+
+	cfg := testConfig(1)
+	cfg.Chrome.Launch.WindowSize = "800,600"
+	if err := ValidateLaunch(cfg); err != nil {
+		t.Fatalf("expected a comma-separated window size to be accepted, got %v", err)
+	}
+}
@@ -15,32 +15,81 @@ import (
 
 	"pdf-renderer/internal/config"
 	"pdf-renderer/internal/infra/logging"
+	"pdf-renderer/internal/infra/metrics"
 )
 
 // Tab represents a single-use Chrome tab (chromedp context) created from a shared browser instance.
 type Tab struct {
 	Ctx    context.Context
 	Cancel context.CancelFunc
+
+	// QueuePosition is the scheduler queue position at enqueue time (0 if a tab was free right away).
+	QueuePosition int
+	// QueueWait is how long the request waited in the scheduler queue.
+	QueueWait time.Duration
+
+	browser    *browser
+	generation uint64 // browser generation the tab was created from
 }
 
-// Pool keeps one long-lived Chromium process warm and limits concurrent renders.
-// Instead of reusing the same tab across requests (which is often brittle after PrintToPDF),
-// each Acquire creates a fresh tab context and Release closes it. Concurrency is controlled
-// by a semaphore with size = chrome_pool_size.
-type Pool struct {
-	cfg config.Config
+// Process returns the index of the Chromium process serving this tab.
+func (t *Tab) Process() int {
+	if t == nil || t.browser == nil {
+		return -1
+	}
+	return t.browser.id
+}
+
+// browser is one Chromium process with its own tab budget.
+// All mutable fields are guarded by Pool.mu.
+type browser struct {
+	id     int
+	budget int // max concurrent tabs on this process
+	inUse  int
 
 	allocCtx      context.Context
 	allocCancel   context.CancelFunc
 	browserCtx    context.Context
 	browserCancel context.CancelFunc
 
-	sem chan struct{} // concurrency tokens
+	profileDir  string
+	generation  uint64 // incremented on every (re)start
+	restarts    uint64
+	lastRestart time.Time
+
+	startedAt time.Time
+	renders   int    // tabs handed out since the last (re)start
+	pid       int    // local Chromium PID once the process is running (0 if unknown)
+	starting  bool   // warming up after a (re)start; not eligible for new tabs
+	draining  bool   // pending restart or recycle; not eligible for new tabs
+	recycleBy string // reason for the pending recycle
+
+	restarting chan struct{} // non-nil while a restart is in progress; closed when it completes
+
+	reconnectAttempts int // consecutive failed connects to a remote browser
+
+	healthy       bool // started successfully and passing probes
+	probeFailures int  // consecutive failed probes (kept across restarts until a probe succeeds)
+	lastProbe     time.Time
+	lastProbeErr  string
+}
 
-	profileDir string
+// Pool keeps one or more long-lived Chromium processes warm and limits concurrent renders.
+// Instead of reusing the same tab across requests (which is often brittle after PrintToPDF),
+// each Acquire creates a fresh tab context and Release closes it. Concurrency is controlled
+// per process: chrome_pool_size tabs are split across chrome.processes browsers, and Acquire
+// dispatches to the least-loaded one. When every tab is busy, requests wait in a scheduler
+// queue that serves interactive before batch work and round-robins between tenants.
+// A crashed browser is restarted on its own without affecting renders running on the other processes.
+type Pool struct {
+	cfg config.Config
 
-	mu     sync.Mutex
-	closed bool
+	mu       sync.Mutex
+	browsers []*browser
+	wake     chan struct{} // closed (and replaced) whenever capacity may have become available
+	sched    scheduler     // queued Acquire calls waiting for a free tab
+	closed   bool
+	done     chan struct{} // closed by Close to stop background goroutines
 
 	restarts    uint64
 	lastRestart atomic.Value // stores time.Time
@@ -48,14 +97,35 @@ type Pool struct {
 
 // Stats is a lightweight snapshot for observability.
 type Stats struct {
-	Enabled      bool   `json:"enabled"`
-	Capacity     int    `json:"capacity"`
-	Idle         int    `json:"idle"`
-	InUse        int    `json:"in_use"`
-	PoolSizeConf int    `json:"pool_size_conf"`
-	ProfileDir   string `json:"profile_dir"`
-	Restarts     uint64 `json:"restarts"`
-	LastRestart  string `json:"last_restart,omitempty"`
+	Enabled      bool           `json:"enabled"`
+	Capacity     int            `json:"capacity"`
+	Idle         int            `json:"idle"`
+	InUse        int            `json:"in_use"`
+	PoolSizeConf int            `json:"pool_size_conf"`
+	ProfileDir   string         `json:"profile_dir"`
+	Restarts     uint64         `json:"restarts"`
+	LastRestart  string         `json:"last_restart,omitempty"`
+	Ready        bool           `json:"ready"`
+	Processes    []ProcessStats `json:"processes"`
+	Queue        QueueStats     `json:"queue"`
+}
+
+// ProcessStats describes a single Chromium process of the pool.
+type ProcessStats struct {
+	ID          int    `json:"id"`
+	Capacity    int    `json:"capacity"`
+	InUse       int    `json:"in_use"`
+	ProfileDir  string `json:"profile_dir"`
+	Restarts    uint64 `json:"restarts"`
+	LastRestart string `json:"last_restart,omitempty"`
+	Renders     int    `json:"renders"`
+	UptimeSecs  int64  `json:"uptime_secs"`
+	Draining    bool   `json:"draining"`
+
+	Healthy        bool   `json:"healthy"`
+	ProbeFailures  int    `json:"probe_failures"`
+	LastProbe      string `json:"last_probe,omitempty"`
+	LastProbeError string `json:"last_probe_error,omitempty"`
 }
 
 func NewPool(cfg config.Config) (*Pool, error) {
@@ -63,154 +133,357 @@ func NewPool(cfg config.Config) (*Pool, error) {
 		return nil, fmt.Errorf("chrome pool disabled (chrome_pool_size <= 0)")
 	}
 
-	profileDir, err := createProfileDir(cfg)
-	if err != nil {
+	if err := validateMode(cfg); err != nil {
+		return nil, err
+	}
+	if err := ValidateLaunch(cfg); err != nil {
 		return nil, err
 	}
 
-	// chromedp.DefaultExecAllocatorOptions may be an array in some versions.
-	// Convert it to a slice before using variadic expansion.
-	opts := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
+	budgets := splitBudget(cfg.PDF.ChromePoolSize, cfg.Chrome.Processes)
 
-	// Only override exec path when configured.
-	if cfg.PDF.ChromePath != "" {
-		opts = append(opts, chromedp.ExecPath(cfg.PDF.ChromePath))
+	p := &Pool{cfg: cfg, done: make(chan struct{})}
+	for i, budget := range budgets {
+		b := &browser{id: i, budget: budget}
+		if err := p.startBrowser(b); err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.browsers = append(p.browsers, b)
 	}
 
-	// Stable-ish flags for containers.
-	opts = append(opts,
-		chromedp.UserDataDir(profileDir),
-		chromedp.NoFirstRun,
-		chromedp.NoDefaultBrowserCheck,
+	// Warm up every Chrome process once.
+	var wg sync.WaitGroup
+	for _, b := range p.browsers {
+		wg.Add(1)
+		go func(b *browser) {
+			defer wg.Done()
+			p.warmUp(b)
+		}(b)
+	}
+	wg.Wait()
 
-		// Avoid Vulkan/ANGLE issues in minimal container environments.
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("disable-gpu-compositing", true),
-		chromedp.Flag("disable-features", "Vulkan,UseSkiaRenderer"),
-		chromedp.Flag("use-gl", "swiftshader"),
+	if interval := p.recycleCheckInterval(); interval > 0 {
+		go p.recycleMonitor(interval)
+	}
+	if cfg.Chrome.Probe.Enabled {
+		interval, _, _ := p.probeSettings()
+		go p.prober(interval)
+	}
 
-		// I still recommend shm_size in compose, but keep this as a safe default.
-		chromedp.Flag("disable-dev-shm-usage", true),
+	logging.Info("Chrome pool initialized", "tabs", cfg.PDF.ChromePoolSize, "processes", len(budgets))
+	return p, nil
+}
 
-		// Reduce background noise.
-		chromedp.Flag("disable-background-networking", true),
-		chromedp.Flag("disable-background-timer-throttling", true),
-		chromedp.Flag("disable-breakpad", true),
-		chromedp.Flag("disable-client-side-phishing-detection", true),
-		chromedp.Flag("disable-component-update", true),
-		chromedp.Flag("disable-default-apps", true),
-		chromedp.Flag("disable-domain-reliability", true),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-sync", true),
-	)
+// warmUp starts (or, in remote mode, connects to) the Chromium process behind b so that tabs
+// are created on it (rather than each tab allocating its own browser) and makes b eligible for
+// new tabs. In remote mode, b is then watched and reconnected when the connection drops.
+func (p *Pool) warmUp(b *browser) {
+	p.mu.Lock()
+	browserCtx := b.browserCtx
+	generation := b.generation
+	p.mu.Unlock()
 
-	if cfg.PDF.ChromeNoSandbox {
-		opts = append(opts, chromedp.Flag("no-sandbox", true))
+	warmupTimeout := time.Duration(p.cfg.PDF.TimeoutSecs) * time.Second
+	if warmupTimeout < 10*time.Second {
+		warmupTimeout = 10 * time.Second
 	}
+	warmupCtx, cancel := context.WithTimeout(browserCtx, warmupTimeout)
+	warmupErr := chromedp.Run(warmupCtx, chromedp.Navigate("about:blank"))
+	cancel()
 
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	pid := 0
+	if c := chromedp.FromContext(browserCtx); c != nil && c.Browser != nil {
+		if proc := c.Browser.Process(); proc != nil {
+			pid = proc.Pid
+		}
+	}
 
-	p := &Pool{
-		cfg:           cfg,
-		allocCtx:      allocCtx,
-		allocCancel:   allocCancel,
-		browserCtx:    browserCtx,
-		browserCancel: browserCancel,
-		sem:           make(chan struct{}, cfg.PDF.ChromePoolSize),
-		profileDir:    profileDir,
+	p.mu.Lock()
+	if b.generation == generation {
+		b.pid = pid
+		b.starting = false
+		b.healthy = warmupErr == nil
+		if warmupErr != nil {
+			b.lastProbeErr = warmupErr.Error()
+		}
 	}
-	for i := 0; i < cfg.PDF.ChromePoolSize; i++ {
-		p.sem <- struct{}{}
+	p.broadcastLocked()
+	p.mu.Unlock()
+
+	if IsRemote(p.cfg) {
+		go p.watchRemote(b, generation, browserCtx, warmupErr == nil)
 	}
+}
 
-	// Warm up Chrome once.
-	warmupTimeout := time.Duration(cfg.PDF.TimeoutSecs) * time.Second
-	if warmupTimeout < 10*time.Second {
-		warmupTimeout = 10 * time.Second
+// splitBudget distributes tabs evenly across processes (at least one tab per process).
+func splitBudget(tabs, processes int) []int {
+	if processes <= 0 {
+		processes = 1
 	}
-	warmupCtx, cancel := context.WithTimeout(browserCtx, warmupTimeout)
-	_ = chromedp.Run(warmupCtx, chromedp.Navigate("about:blank"))
-	cancel()
+	if processes > tabs {
+		processes = tabs
+	}
+	budgets := make([]int, processes)
+	for i := range budgets {
+		budgets[i] = tabs / processes
+		if i < tabs%processes {
+			budgets[i]++
+		}
+	}
+	return budgets
+}
 
-	logging.Info("Chrome pool initialized", "tabs", cfg.PDF.ChromePoolSize, "profile_dir", profileDir)
-	return p, nil
+// startBrowser creates a fresh profile directory and allocator/browser contexts for b.
+// In remote mode, no local profile is created; the allocator connects to chrome.remote_url.
+// Callers must either hold p.mu or own b exclusively.
+func (p *Pool) startBrowser(b *browser) error {
+	profileDir := ""
+	if IsRemote(p.cfg) {
+		b.allocCtx, b.allocCancel = NewRemoteAllocator(context.Background(), p.cfg)
+	} else {
+		dir, err := createProfileDir(p.cfg)
+		if err != nil {
+			return err
+		}
+		opts, err := ExecAllocatorOptions(p.cfg, dir)
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return err
+		}
+		profileDir = dir
+		b.allocCtx, b.allocCancel = chromedp.NewExecAllocator(context.Background(), opts...)
+	}
+	b.browserCtx, b.browserCancel = chromedp.NewContext(b.allocCtx)
+	b.profileDir = profileDir
+	b.generation++
+	b.startedAt = time.Now()
+	b.renders = 0
+	b.pid = 0
+	b.starting = true
+	b.draining = false
+	b.recycleBy = ""
+	return nil
+}
+
+// stop cancels the browser contexts, which terminates the Chromium process.
+func (b *browser) stop() {
+	if b.browserCancel != nil {
+		b.browserCancel()
+	}
+	if b.allocCancel != nil {
+		b.allocCancel()
+	}
 }
 
-// Acquire blocks until capacity is available or ctx is cancelled.
-// It returns a fresh tab context; callers must Release it.
+// Acquire blocks until capacity is available or ctx is cancelled, queueing as an anonymous
+// interactive request. See AcquireFor.
 func (p *Pool) Acquire(ctx context.Context) (*Tab, error) {
+	return p.AcquireFor(ctx, AcquireOptions{})
+}
+
+// AcquireFor returns a fresh tab context on the least-loaded process; callers must Release it.
+// When no tab is free, the request is queued by the scheduler (interactive before batch, tenants
+// served in turn) until capacity frees up, ctx is cancelled or the class wait timeout elapses.
+func (p *Pool) AcquireFor(ctx context.Context, opts AcquireOptions) (*Tab, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.Priority < 0 || opts.Priority >= numPriorities {
+		opts.Priority = PriorityInteractive
+	}
+
 	p.mu.Lock()
-	closed := p.closed
-	p.mu.Unlock()
-	if closed {
+	if p.closed {
+		p.mu.Unlock()
 		return nil, errors.New("chrome pool is closed")
 	}
+	// Fast path: nobody is queued and a tab is free.
+	if p.sched.depth == 0 {
+		if b := p.leastLoadedLocked(); b != nil {
+			g := p.reserveLocked(b)
+			p.mu.Unlock()
+			metrics.QueueWait.WithLabelValues(opts.Priority.String()).Observe(0)
+			return p.newTab(g, 0, 0), nil
+		}
+	}
 
+	w := &waiter{tenant: opts.Tenant, priority: opts.Priority, enqueued: time.Now(), ready: make(chan grant, 1)}
+	if err := p.sched.push(w, p.cfg.Scheduler.QueueDepth, p.cfg.Scheduler.TenantQueueDepth); err != nil {
+		p.mu.Unlock()
+		metrics.QueueRejections.WithLabelValues(opts.Priority.String()).Inc()
+		return nil, err
+	}
+	p.mu.Unlock()
+
+	timer := time.NewTimer(p.waitTimeout(opts.Priority))
+	defer timer.Stop()
+
+	var waitErr error
 	select {
+	case g, ok := <-w.ready:
+		if !ok {
+			return nil, errors.New("chrome pool is closed")
+		}
+		wait := time.Since(w.enqueued)
+		metrics.QueueWait.WithLabelValues(opts.Priority.String()).Observe(wait.Seconds())
+		return p.newTab(g, w.position, wait), nil
 	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-p.sem:
-		// ok
+		waitErr = ctx.Err()
+	case <-timer.C:
+		waitErr = ErrQueueTimeout
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.sched.remove(w) {
+		// Served (or closed) concurrently: hand the reserved slot back.
+		if g, ok := <-w.ready; ok {
+			p.unreserveLocked(g.b)
+		}
 	}
+	if waitErr == ErrQueueTimeout {
+		p.sched.timedOut.Add(1)
+		metrics.AcquireTimeouts.WithLabelValues(opts.Priority.String()).Inc()
+	}
+	return nil, waitErr
+}
 
-	// Create a fresh tab for this request.
-	tabCtx, cancel := chromedp.NewContext(p.browserCtx)
-	return &Tab{Ctx: tabCtx, Cancel: cancel}, nil
+// reserveLocked takes one tab slot on b.
+func (p *Pool) reserveLocked(b *browser) grant {
+	b.inUse++
+	metrics.TabsInUse.Inc()
+	b.renders++
+	g := grant{b: b, generation: b.generation}
+	if max := p.cfg.Chrome.Recycle.MaxRenders; max > 0 && b.renders >= max {
+		p.markRecycleLocked(b, "max_renders")
+	}
+	return g
 }
 
-// Release closes the tab and returns the capacity token.
-// renderErr is ignored (kept for backwards compatibility).
-func (p *Pool) Release(t *Tab, _ error) {
-	if t != nil && t.Cancel != nil {
-		t.Cancel()
+// unreserveLocked returns one tab slot to b and passes the capacity on to waiters.
+func (p *Pool) unreserveLocked(b *browser) {
+	if b.inUse > 0 {
+		b.inUse--
+		metrics.TabsInUse.Dec()
 	}
+	p.broadcastLocked()
+}
 
+// newTab creates a fresh tab for a reserved slot.
+func (p *Pool) newTab(g grant, position int, wait time.Duration) *Tab {
 	p.mu.Lock()
-	closed := p.closed
+	browserCtx := g.b.browserCtx
 	p.mu.Unlock()
-	if closed {
+
+	tabCtx, cancel := chromedp.NewContext(browserCtx)
+	return &Tab{Ctx: tabCtx, Cancel: cancel, browser: g.b, generation: g.generation, QueuePosition: position, QueueWait: wait}
+}
+
+// leastLoadedLocked returns the process with the lowest relative load that still has a free tab.
+func (p *Pool) leastLoadedLocked() *browser {
+	var best *browser
+	for _, b := range p.browsers {
+		if b.starting || b.draining || b.inUse >= b.budget {
+			continue
+		}
+		// Compare inUse/budget without floating point.
+		if best == nil || b.inUse*best.budget < best.inUse*b.budget {
+			best = b
+		}
+	}
+	return best
+}
+
+func (p *Pool) wakeChanLocked() chan struct{} {
+	if p.wake == nil {
+		p.wake = make(chan struct{})
+	}
+	return p.wake
+}
+
+// broadcastLocked hands free capacity to queued Acquire calls and wakes up every goroutine
+// waiting for a change in tab usage (e.g. a draining restart).
+func (p *Pool) broadcastLocked() {
+	p.dispatchLocked()
+	if p.wake != nil {
+		close(p.wake)
+		p.wake = nil
+	}
+}
+
+// Release closes the tab and returns its capacity to the owning process.
+// renderErr is ignored (kept for backwards compatibility).
+func (p *Pool) Release(t *Tab, _ error) {
+	if t == nil {
 		return
 	}
+	if t.Cancel != nil {
+		t.Cancel()
+	}
 
-	// Return token (never blocks).
-	select {
-	case p.sem <- struct{}{}:
-	default:
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t.browser != nil {
+		p.unreserveLocked(t.browser)
 	}
 }
 
 func (p *Pool) Stats(timeoutSecs int) Stats {
 	p.mu.Lock()
-	closed := p.closed
-	profile := p.profileDir
-	poolSize := p.cfg.PDF.ChromePoolSize
-	p.mu.Unlock()
+	defer p.mu.Unlock()
 
-	capacity := cap(p.sem)
-	idle := len(p.sem)
-	inUse := capacity - idle
+	s := Stats{
+		Enabled:      !p.closed && p.cfg.PDF.ChromePoolSize > 0,
+		PoolSizeConf: p.cfg.PDF.ChromePoolSize,
+		Restarts:     atomic.LoadUint64(&p.restarts),
+		Processes:    make([]ProcessStats, 0, len(p.browsers)),
+		Queue:        p.sched.stats(),
+	}
+	for _, b := range p.browsers {
+		s.Capacity += b.budget
+		s.InUse += b.inUse
+		ps := ProcessStats{
+			ID:         b.id,
+			Capacity:   b.budget,
+			InUse:      b.inUse,
+			ProfileDir: b.profileDir,
+			Restarts:   b.restarts,
+			Renders:    b.renders,
+			Draining:   b.draining,
+
+			Healthy:        b.healthy,
+			ProbeFailures:  b.probeFailures,
+			LastProbeError: b.lastProbeErr,
+		}
+		if !b.lastProbe.IsZero() {
+			ps.LastProbe = b.lastProbe.UTC().Format(time.RFC3339)
+		}
+		if b.healthy {
+			s.Ready = !p.closed
+		}
+		if !b.startedAt.IsZero() {
+			ps.UptimeSecs = int64(time.Since(b.startedAt).Seconds())
+		}
+		if !b.lastRestart.IsZero() {
+			ps.LastRestart = b.lastRestart.UTC().Format(time.RFC3339)
+		}
+		s.Processes = append(s.Processes, ps)
+	}
+	s.Idle = s.Capacity - s.InUse
+	if len(p.browsers) > 0 {
+		s.ProfileDir = p.browsers[0].profileDir
+	}
 
-	var lastRestart string
 	if v := p.lastRestart.Load(); v != nil {
 		if t, ok := v.(time.Time); ok && !t.IsZero() {
-			lastRestart = t.UTC().Format(time.RFC3339)
+			s.LastRestart = t.UTC().Format(time.RFC3339)
 		}
 	}
-
-	return Stats{
-		Enabled:      !closed && poolSize > 0,
-		Capacity:     capacity,
-		Idle:         idle,
-		InUse:        inUse,
-		PoolSizeConf: poolSize,
-		ProfileDir:   profile,
-		Restarts:     atomic.LoadUint64(&p.restarts),
-		LastRestart:  lastRestart,
-	}
+	return s
 }
 
-// Restart tears down and recreates the underlying Chromium process/profile.
+// Restart gracefully recreates every Chromium process/profile of the pool.
 // This is useful when Chrome/DevTools becomes unstable (e.g. "context canceled" / target closed).
 func (p *Pool) Restart() error {
 	p.mu.Lock()
@@ -218,65 +491,122 @@ func (p *Pool) Restart() error {
 		p.mu.Unlock()
 		return errors.New("chrome pool is closed")
 	}
-	oldProfile := p.profileDir
-	// Cancel old contexts.
-	if p.browserCancel != nil {
-		p.browserCancel()
+	browsers := append([]*browser(nil), p.browsers...)
+	p.mu.Unlock()
+
+	errs := make([]error, len(browsers))
+	var wg sync.WaitGroup
+	for i, b := range browsers {
+		wg.Add(1)
+		go func(i int, b *browser) {
+			defer wg.Done()
+			errs[i] = p.restartBrowser(b, 0, "manual")
+		}(i, b)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// RestartFor restarts only the Chromium process that served t. If that process has already
+// been restarted since t was acquired, RestartFor is a no-op.
+func (p *Pool) RestartFor(t *Tab) error {
+	if t == nil || t.browser == nil {
+		return p.Restart()
+	}
+	return p.restartBrowser(t.browser, t.generation, "session")
+}
+
+// restartDrainTimeout bounds how long a restart waits for in-flight tabs (default timeout_secs).
+func (p *Pool) restartDrainTimeout() time.Duration {
+	if d := p.cfg.Chrome.RestartDrainTimeout; d > 0 {
+		return d
 	}
-	if p.allocCancel != nil {
-		p.allocCancel()
+	if p.cfg.PDF.TimeoutSecs > 0 {
+		return time.Duration(p.cfg.PDF.TimeoutSecs) * time.Second
 	}
+	return 10 * time.Second
+}
 
-	// New profile directory.
-	profileDir, err := createProfileDir(p.cfg)
-	if err != nil {
+// restartBrowser gracefully recreates b: it stops admitting new tabs, waits (bounded by
+// restartDrainTimeout) for in-flight tabs to be released, then swaps in a fresh, warmed-up
+// process. Concurrent restarts of the same process are coalesced: callers arriving while a
+// restart is in progress wait for it instead of starting another one. With a non-zero
+// generation, the restart only happens if b is still running that generation, so concurrent
+// failures on the same process restart it once. reason labels the restart in metrics and logs.
+func (p *Pool) restartBrowser(b *browser, generation uint64, reason string) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return errors.New("chrome pool is closed")
+	}
+	if b.restarting != nil {
+		done := b.restarting
+		p.mu.Unlock()
+		<-done
+		return nil
+	}
+	if generation != 0 && b.generation != generation {
+		p.mu.Unlock()
+		return nil
+	}
+
+	done := make(chan struct{})
+	b.restarting = done
+	b.draining = true
+	defer func() {
+		p.mu.Lock()
+		b.restarting = nil
+		p.mu.Unlock()
+		close(done)
+	}()
+
+	deadline := time.NewTimer(p.restartDrainTimeout())
+	defer deadline.Stop()
+	for b.inUse > 0 && !p.closed {
+		wake := p.wakeChanLocked()
+		p.mu.Unlock()
+		timedOut := false
+		select {
+		case <-wake:
+		case <-deadline.C:
+			timedOut = true
+		}
+		p.mu.Lock()
+		if timedOut {
+			logging.Warn("Chrome restart drain timed out; terminating in-flight renders", "process", b.id, "in_use", b.inUse)
+			break
+		}
+	}
+	if p.closed {
+		p.mu.Unlock()
+		return errors.New("chrome pool is closed")
+	}
+
+	oldProfile := b.profileDir
+	b.stop()
+	if err := p.startBrowser(b); err != nil {
+		b.draining = false
 		p.mu.Unlock()
 		return err
 	}
-	p.profileDir = profileDir
-
-	// Recreate allocator + browser contexts.
-	opts := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
-	if p.cfg.PDF.ChromePath != "" {
-		opts = append(opts, chromedp.ExecPath(p.cfg.PDF.ChromePath))
-	}
-	opts = append(opts,
-		chromedp.UserDataDir(profileDir),
-		chromedp.NoFirstRun,
-		chromedp.NoDefaultBrowserCheck,
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("disable-gpu-compositing", true),
-		chromedp.Flag("disable-features", "Vulkan,UseSkiaRenderer"),
-		chromedp.Flag("use-gl", "swiftshader"),
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.Flag("disable-background-networking", true),
-		chromedp.Flag("disable-background-timer-throttling", true),
-		chromedp.Flag("disable-breakpad", true),
-		chromedp.Flag("disable-client-side-phishing-detection", true),
-		chromedp.Flag("disable-component-update", true),
-		chromedp.Flag("disable-default-apps", true),
-		chromedp.Flag("disable-domain-reliability", true),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-sync", true),
-	)
-	if p.cfg.PDF.ChromeNoSandbox {
-		opts = append(opts, chromedp.Flag("no-sandbox", true))
-	}
-
-	p.allocCtx, p.allocCancel = chromedp.NewExecAllocator(context.Background(), opts...)
-	p.browserCtx, p.browserCancel = chromedp.NewContext(p.allocCtx)
+	now := time.Now()
+	b.restarts++
+	b.lastRestart = now
+	profileDir := b.profileDir
 
 	atomic.AddUint64(&p.restarts, 1)
-	p.lastRestart.Store(time.Now())
-
+	p.lastRestart.Store(now)
 	p.mu.Unlock()
+	metrics.PoolRestarts.WithLabelValues(reason).Inc()
 
 	// Best-effort cleanup of old profile directory.
 	if oldProfile != "" {
 		_ = os.RemoveAll(oldProfile)
 	}
 
-	logging.Warn("Chrome pool restarted", "profile_dir", profileDir)
+	p.warmUp(b)
+
+	logging.Warn("Chrome process restarted", "process", b.id, "reason", reason, "profile_dir", profileDir)
 	return nil
 }
 
@@ -287,17 +617,19 @@ func (p *Pool) Close() {
 		return
 	}
 	p.closed = true
-	profile := p.profileDir
+	browsers := p.browsers
+	p.sched.closeAll()
+	p.broadcastLocked()
+	if p.done != nil {
+		close(p.done)
+	}
 	p.mu.Unlock()
 
-	if p.browserCancel != nil {
-		p.browserCancel()
-	}
-	if p.allocCancel != nil {
-		p.allocCancel()
-	}
-	if profile != "" {
-		_ = os.RemoveAll(profile)
+	for _, b := range browsers {
+		b.stop()
+		if b.profileDir != "" {
+			_ = os.RemoveAll(b.profileDir)
+		}
 	}
 }
 
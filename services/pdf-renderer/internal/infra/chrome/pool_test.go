@@ -43,9 +43,17 @@ func TestCreateProfileDir_DefaultAndCustomBase(t *testing.T) {
 	}
 }
 
+// newTestPool builds a pool without launching Chromium. Each budget creates one process.
+func newTestPool(cfg config.Config, budgets ...int) *Pool {
+	p := &Pool{cfg: cfg}
+	for i, budget := range budgets {
+		p.browsers = append(p.browsers, &browser{id: i, budget: budget, browserCtx: context.Background(), generation: 1})
+	}
+	return p
+}
+
 func TestPoolAcquireReleaseAndClose(t *testing.T) {
-	p := &Pool{sem: make(chan struct{}, 1), browserCtx: context.Background()}
-	p.sem <- struct{}{}
+	p := newTestPool(testConfig(1), 1)
 
 	tab, err := p.Acquire(context.Background())
 	if err != nil {
@@ -54,13 +62,13 @@ func TestPoolAcquireReleaseAndClose(t *testing.T) {
 	if tab == nil {
 		t.Fatalf("expected non-nil tab")
 	}
-	if len(p.sem) != 0 {
-		t.Fatalf("expected token consumed after acquire")
+	if p.browsers[0].inUse != 1 {
+		t.Fatalf("expected capacity consumed after acquire")
 	}
 
 	p.Release(tab, nil)
-	if len(p.sem) != 1 {
-		t.Fatalf("expected token returned after release")
+	if p.browsers[0].inUse != 0 {
+		t.Fatalf("expected capacity returned after release")
 	}
 
 	p.closed = true
@@ -70,7 +78,7 @@ func TestPoolAcquireReleaseAndClose(t *testing.T) {
 }
 
 func TestPoolAcquireContextCanceled(t *testing.T) {
-	p := &Pool{sem: make(chan struct{}, 1), browserCtx: context.Background()}
+	p := newTestPool(testConfig(1), 1)
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 	if _, err := p.Acquire(ctx); !errors.Is(err, context.Canceled) {
@@ -79,7 +87,8 @@ func TestPoolAcquireContextCanceled(t *testing.T) {
 }
 
 func TestPoolAcquireTimesOutWhenNoCapacity(t *testing.T) {
-	p := &Pool{sem: make(chan struct{}, 1), browserCtx: context.Background()}
+	p := newTestPool(testConfig(1), 1)
+	p.browsers[0].inUse = 1
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
 	defer cancel()
 	_, err := p.Acquire(ctx)
@@ -88,22 +97,98 @@ func TestPoolAcquireTimesOutWhenNoCapacity(t *testing.T) {
 	}
 }
 
+func TestPoolAcquireWaitsForRelease(t *testing.T) {
+	p := newTestPool(testConfig(1), 1)
+	first, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		p.Release(first, nil)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	second, err := p.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected waiting acquire to succeed after release, got %v", err)
+	}
+	p.Release(second, nil)
+}
+
+func TestPoolAcquireDispatchesToLeastLoadedProcess(t *testing.T) {
+	p := newTestPool(testConfig(4), 2, 2)
+
+	var tabs []*Tab
+	for i := 0; i < 4; i++ {
+		tab, err := p.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+		tabs = append(tabs, tab)
+	}
+	// Tabs alternate between processes instead of filling one first.
+	for i, tab := range tabs {
+		if tab.Process() != i%2 {
+			t.Fatalf("tab %d: expected process %d, got %d", i, i%2, tab.Process())
+		}
+	}
+
+	// Free a slot on process 1; the next tab must go there.
+	p.Release(tabs[1], nil)
+	tab, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if tab.Process() != 1 {
+		t.Fatalf("expected tab on process 1, got %d", tab.Process())
+	}
+}
+
+func TestSplitBudget(t *testing.T) {
+	tests := []struct {
+		tabs, processes int
+		want            []int
+	}{
+		{4, 0, []int{4}},
+		{4, 1, []int{4}},
+		{4, 2, []int{2, 2}},
+		{5, 2, []int{3, 2}},
+		{2, 4, []int{1, 1}},
+	}
+	for _, tc := range tests {
+		got := splitBudget(tc.tabs, tc.processes)
+		if len(got) != len(tc.want) {
+			t.Fatalf("splitBudget(%d, %d) = %v, want %v", tc.tabs, tc.processes, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("splitBudget(%d, %d) = %v, want %v", tc.tabs, tc.processes, got, tc.want)
+			}
+		}
+	}
+}
+
 func TestPoolStatsAndClose(t *testing.T) {
-	p := &Pool{sem: make(chan struct{}, 2), cfg: testConfig(2), profileDir: t.TempDir(), browserCtx: context.Background()}
-	p.sem <- struct{}{}
-	p.sem <- struct{}{}
+	p := newTestPool(testConfig(2), 2)
+	p.browsers[0].profileDir = t.TempDir()
 
 	st := p.Stats(1)
 	if !st.Enabled || st.Capacity != 2 || st.Idle != 2 || st.InUse != 0 {
 		t.Fatalf("unexpected stats before acquire: %+v", st)
 	}
+	if len(st.Processes) != 1 || st.ProfileDir != p.browsers[0].profileDir {
+		t.Fatalf("unexpected process stats: %+v", st)
+	}
 
 	tab, err := p.Acquire(context.Background())
 	if err != nil {
 		t.Fatalf("acquire: %v", err)
 	}
 	st = p.Stats(1)
-	if st.InUse != 1 {
+	if st.InUse != 1 || st.Processes[0].InUse != 1 {
 		t.Fatalf("expected one in use, got %+v", st)
 	}
 	p.Release(tab, nil)
@@ -141,18 +226,14 @@ func TestNewPool_Disabled(t *testing.T) {
 func TestPoolRestart_Success(t *testing.T) {
 	cfg := testConfig(1)
 	old := t.TempDir()
-	p := &Pool{
-		cfg:        cfg,
-		sem:        make(chan struct{}, 1),
-		profileDir: old,
-	}
-	p.sem <- struct{}{}
+	p := newTestPool(cfg, 1)
+	p.browsers[0].profileDir = old
 
 	if err := p.Restart(); err != nil {
 		t.Fatalf("expected restart success, got %v", err)
 	}
-	if p.profileDir == "" || p.profileDir == old {
-		t.Fatalf("expected new profile dir, got %q", p.profileDir)
+	if dir := p.browsers[0].profileDir; dir == "" || dir == old {
+		t.Fatalf("expected new profile dir, got %q", dir)
 	}
 	if p.Stats(1).Restarts < 1 {
 		t.Fatalf("expected restart counter increment")
@@ -160,6 +241,34 @@ func TestPoolRestart_Success(t *testing.T) {
 	p.Close()
 }
 
+func TestPoolRestartFor_OnlyAffectedProcessOnce(t *testing.T) {
+	p := newTestPool(testConfig(2), 1, 1)
+	tab0, _ := p.Acquire(context.Background())
+	tab1, _ := p.Acquire(context.Background())
+	if tab0.Process() == tab1.Process() {
+		t.Fatalf("expected tabs on different processes")
+	}
+	p.Release(tab0, nil)
+	p.Release(tab1, nil)
+
+	if err := p.RestartFor(tab0); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	// A second failure observed on the same (old) generation must not restart again.
+	if err := p.RestartFor(tab0); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+
+	st := p.Stats(1)
+	if st.Processes[tab0.Process()].Restarts != 1 {
+		t.Fatalf("expected failing process restarted exactly once, got %+v", st.Processes)
+	}
+	if st.Processes[tab1.Process()].Restarts != 0 {
+		t.Fatalf("expected other process untouched, got %+v", st.Processes)
+	}
+	p.Close()
+}
+
 func TestNewPool_WithDummyExecPath(t *testing.T) {
 	cfg := testConfig(1)
 	cfg.PDF.ChromePath = "/bin/true"
@@ -171,6 +280,9 @@ func TestNewPool_WithDummyExecPath(t *testing.T) {
 	if p == nil {
 		t.Fatalf("expected non-nil pool")
 	}
+	if got := len(p.Stats(1).Processes); got != 1 {
+		t.Fatalf("expected one process by default, got %d", got)
+	}
 	tab, err := p.Acquire(context.Background())
 	if err != nil {
 		t.Fatalf("acquire should work: %v", err)
@@ -199,3 +311,110 @@ func TestIsSessionInterrupted(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPool_MultipleProcesses(t *testing.T) {
+	cfg := testConfig(3)
+	cfg.PDF.ChromePath = "/bin/true"
+	cfg.Chrome.Processes = 2
+
+	p, err := NewPool(cfg)
+	if err != nil {
+		t.Fatalf("expected pool init success, got %v", err)
+	}
+	defer p.Close()
+
+	st := p.Stats(1)
+	if st.Capacity != 3 || len(st.Processes) != 2 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	if st.Processes[0].ProfileDir == st.Processes[1].ProfileDir {
+		t.Fatalf("expected separate profile dirs per process")
+	}
+}
+
+func TestPoolRestart_DrainsInFlightTabs(t *testing.T) {
+	cfg := testConfig(2)
+	cfg.PDF.ChromePath = "/bin/true"
+	cfg.Chrome.RestartDrainTimeout = 5 * time.Second
+	p := newTestPool(cfg, 2)
+	defer p.Close()
+
+	tab, _ := p.Acquire(context.Background())
+	restarted := make(chan error, 1)
+	go func() { restarted <- p.RestartFor(tab) }()
+	for !p.Stats(1).Processes[0].Draining {
+		time.Sleep(time.Millisecond)
+	}
+
+	// While draining, the process admits no new tabs and the in-flight tab keeps running.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := p.Acquire(ctx); err == nil {
+		t.Fatalf("expected no new tabs while restart is draining")
+	}
+	if tab.Ctx.Err() != nil {
+		t.Fatalf("expected in-flight tab to stay alive while draining")
+	}
+	select {
+	case <-restarted:
+		t.Fatalf("expected restart to wait for in-flight tab")
+	default:
+	}
+
+	p.Release(tab, nil)
+	if err := <-restarted; err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if st := p.Stats(1).Processes[0]; st.Restarts != 1 || st.Draining {
+		t.Fatalf("unexpected process state after restart: %+v", st)
+	}
+}
+
+func TestPoolRestart_DrainTimeout(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.PDF.ChromePath = "/bin/true"
+	cfg.Chrome.RestartDrainTimeout = 50 * time.Millisecond
+	p := newTestPool(cfg, 1)
+	defer p.Close()
+
+	tab, _ := p.Acquire(context.Background())
+	defer p.Release(tab, nil)
+
+	start := time.Now()
+	if err := p.Restart(); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatalf("expected restart to wait for the drain timeout")
+	}
+	if p.Stats(1).Processes[0].Restarts != 1 {
+		t.Fatalf("expected restart after drain timeout")
+	}
+}
+
+func TestPoolRestart_CoalescesConcurrentRequests(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.PDF.ChromePath = "/bin/true"
+	p := newTestPool(cfg, 1)
+	defer p.Close()
+
+	tab, _ := p.Acquire(context.Background())
+
+	const callers = 4
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() { errs <- p.Restart() }()
+	}
+	// Let every caller join the restart that is waiting for the in-flight tab.
+	time.Sleep(50 * time.Millisecond)
+	p.Release(tab, nil)
+
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("restart: %v", err)
+		}
+	}
+	if got := p.Stats(1).Processes[0].Restarts; got != 1 {
+		t.Fatalf("expected concurrent restarts coalesced into one, got %d", got)
+	}
+}
@@ -0,0 +1,152 @@
+package chrome
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+
+	"pdf-renderer/internal/infra/logging"
+)
+
+const (
+	defaultProbeInterval         = 15 * time.Second
+	defaultProbeTimeout          = 5 * time.Second
+	defaultProbeFailureThreshold = 3
+
+	probePage = "data:text/html,<!doctype html><title>probe</title><p>probe</p>"
+)
+
+// probeSettings returns the effective probe interval, timeout and failure threshold.
+func (p *Pool) probeSettings() (interval, timeout time.Duration, threshold int) {
+	pc := p.cfg.Chrome.Probe
+	interval, timeout, threshold = pc.Interval, pc.Timeout, pc.FailureThreshold
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	if threshold <= 0 {
+		threshold = defaultProbeFailureThreshold
+	}
+	return interval, timeout, threshold
+}
+
+// prober periodically probes every process until the pool is closed.
+func (p *Pool) prober(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.probeAll()
+		}
+	}
+}
+
+// probeAll probes every process that is not (re)starting, in parallel.
+func (p *Pool) probeAll() {
+	type target struct {
+		b          *browser
+		generation uint64
+		browserCtx context.Context
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	targets := make([]target, 0, len(p.browsers))
+	for _, b := range p.browsers {
+		if b.starting || b.restarting != nil {
+			continue
+		}
+		targets = append(targets, target{b: b, generation: b.generation, browserCtx: b.browserCtx})
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.probeBrowser(t.b, t.generation, t.browserCtx)
+		}()
+	}
+	wg.Wait()
+}
+
+// probeBrowser renders a trivial page in a fresh tab on b with a short deadline. The probe tab
+// does not count against the tab budget, so probing never queues behind real renders. After
+// failure_threshold consecutive failures, b is marked unhealthy and restarted.
+func (p *Pool) probeBrowser(b *browser, generation uint64, browserCtx context.Context) {
+	_, timeout, threshold := p.probeSettings()
+
+	start := time.Now()
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+	ctx, cancel := context.WithTimeout(tabCtx, timeout)
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(probePage),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, _, err := page.PrintToPDF().Do(ctx)
+			return err
+		}),
+	)
+	cancel()
+	cancelTab()
+
+	p.mu.Lock()
+	if p.closed || b.generation != generation {
+		p.mu.Unlock()
+		return
+	}
+	b.lastProbe = time.Now()
+	if err == nil {
+		if !b.healthy {
+			logging.Info("Chrome process healthy", "process", b.id, "probe_ms", time.Since(start).Milliseconds())
+		}
+		b.healthy = true
+		b.probeFailures = 0
+		b.lastProbeErr = ""
+		p.mu.Unlock()
+		return
+	}
+
+	b.probeFailures++
+	b.lastProbeErr = err.Error()
+	failures := b.probeFailures
+	restart := failures >= threshold
+	if restart {
+		b.healthy = false
+	}
+	p.mu.Unlock()
+
+	logging.Warn("Chrome probe failed", "process", b.id, "failures", failures, "threshold", threshold, "error", err)
+	if restart {
+		if err := p.restartBrowser(b, generation, "probe"); err != nil {
+			logging.Error("Chrome restart after failed probes failed", "process", b.id, "error", err)
+		}
+	}
+}
+
+// Ready reports whether the pool can currently render: it is open and at least one process is
+// healthy (started successfully and not failing its probes).
+func (p *Pool) Ready() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	for _, b := range p.browsers {
+		if b.healthy {
+			return true
+		}
+	}
+	return false
+}
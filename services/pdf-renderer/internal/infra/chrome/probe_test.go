@@ -0,0 +1,65 @@
+package chrome
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProbeSettings_Defaults(t *testing.T) {
+	p := newTestPool(testConfig(1), 1)
+	interval, timeout, threshold := p.probeSettings()
+	if interval != defaultProbeInterval || timeout != defaultProbeTimeout || threshold != defaultProbeFailureThreshold {
+		t.Fatalf("unexpected defaults: %v %v %d", interval, timeout, threshold)
+	}
+
+	cfg := testConfig(1)
+	cfg.Chrome.Probe.Interval = time.Second
+	cfg.Chrome.Probe.Timeout = 2 * time.Second
+	cfg.Chrome.Probe.FailureThreshold = 5
+	interval, timeout, threshold = newTestPool(cfg, 1).probeSettings()
+	if interval != time.Second || timeout != 2*time.Second || threshold != 5 {
+		t.Fatalf("unexpected settings: %v %v %d", interval, timeout, threshold)
+	}
+}
+
+func TestPoolReady(t *testing.T) {
+	p := newTestPool(testConfig(2), 1, 1)
+	if p.Ready() {
+		t.Fatalf("expected not ready before any process is healthy")
+	}
+	p.browsers[1].healthy = true
+	if !p.Ready() || !p.Stats(1).Ready {
+		t.Fatalf("expected ready with one healthy process")
+	}
+	p.Close()
+	if p.Ready() {
+		t.Fatalf("expected not ready after close")
+	}
+}
+
+func TestProbe_FailuresMarkUnhealthyAndRestart(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.PDF.ChromePath = "/bin/true"
+	cfg.Chrome.Probe.FailureThreshold = 2
+	cfg.Chrome.Probe.Timeout = 100 * time.Millisecond
+	p := newTestPool(cfg, 1)
+	defer p.Close()
+	b := p.browsers[0]
+	b.healthy = true
+
+	// The test pool has no running browser, so every probe fails.
+	p.probeAll()
+	st := p.Stats(1).Processes[0]
+	if !st.Healthy || st.ProbeFailures != 1 || st.LastProbeError == "" || st.Restarts != 0 {
+		t.Fatalf("expected one tolerated failure, got %+v", st)
+	}
+
+	p.probeAll()
+	st = p.Stats(1).Processes[0]
+	if st.Healthy || st.Restarts != 1 {
+		t.Fatalf("expected unhealthy process restarted after threshold, got %+v", st)
+	}
+	if p.Ready() {
+		t.Fatalf("expected pool not ready while its only process is broken")
+	}
+}
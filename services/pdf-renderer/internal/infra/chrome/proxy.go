@@ -0,0 +1,137 @@
+package chrome
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"pdf-renderer/internal/config"
+)
+
+// HTTPTransport returns a transport for requests the service makes on behalf of pages (the asset
+// cache), routed through chrome.launch.proxy_server and proxy_bypass_list the way locally started
+// Chromium routes them, so both egress paths reach the same hosts. Without a proxy, and in remote
+// mode where the section is ignored, it uses the proxy environment variables like
+// http.DefaultTransport.
+func HTTPTransport(cfg config.Config) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	launch := cfg.Chrome.Launch
+	if IsRemote(cfg) || launch.ProxyServer == "" {
+		return t, nil
+	}
+	proxy, err := parseProxyServer(launch.ProxyServer)
+	if err != nil {
+		return nil, err
+	}
+	bypass := parseBypassList(launch.ProxyBypassList)
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		if bypass.matches(req.URL) {
+			return nil, nil
+		}
+		return proxy, nil
+	}
+	return t, nil
+}
+
+// parseProxyServer parses a Chromium --proxy-server value with a single proxy. A missing scheme
+// means http, as in Chromium; per-scheme lists ("http=a:80;https=b:443") are not supported.
+func parseProxyServer(s string) (*url.URL, error) {
+	raw := strings.TrimSpace(s)
+	if strings.ContainsAny(raw, ";=") {
+		return nil, fmt.Errorf("chrome.launch.proxy_server %q: per-scheme proxy lists are not supported for asset downloads", s)
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid chrome.launch.proxy_server %q", s)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	}
+	return nil, fmt.Errorf("chrome.launch.proxy_server scheme %q is not supported for asset downloads (http, https or socks5)", u.Scheme)
+}
+
+// bypassRule is one entry of a Chromium proxy bypass list.
+type bypassRule struct {
+	scheme string     // optional, e.g. "https"
+	host   string     // host pattern with '*' wildcards; ".example.com" means "*.example.com"
+	port   string     // optional
+	cidr   *net.IPNet // set for IP ranges such as 10.0.0.0/8
+	local  bool       // <local>: host names without a dot
+}
+
+type bypassList []bypassRule
+
+// parseBypassList parses a proxy_bypass_list: entries separated by ';' or ','.
+func parseBypassList(s string) bypassList {
+	var rules bypassList
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }) {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "<local>" {
+			rules = append(rules, bypassRule{local: true})
+			continue
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			rules = append(rules, bypassRule{cidr: cidr})
+			continue
+		}
+		var r bypassRule
+		if scheme, rest, ok := strings.Cut(entry, "://"); ok {
+			r.scheme, entry = scheme, rest
+		}
+		if host, port, err := net.SplitHostPort(entry); err == nil {
+			entry, r.port = host, port
+		}
+		if strings.HasPrefix(entry, ".") {
+			entry = "*" + entry
+		}
+		r.host = strings.Trim(entry, "[]")
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// matches reports whether u is reached directly. Like Chromium, loopback hosts always are.
+func (l bypassList) matches(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	ip := net.ParseIP(host)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	for _, r := range l {
+		switch {
+		case r.local:
+			if ip == nil && !strings.Contains(host, ".") {
+				return true
+			}
+		case r.cidr != nil:
+			if ip != nil && r.cidr.Contains(ip) {
+				return true
+			}
+		default:
+			if r.scheme != "" && r.scheme != u.Scheme {
+				continue
+			}
+			if r.port != "" && r.port != port {
+				continue
+			}
+			if ok, _ := path.Match(r.host, host); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
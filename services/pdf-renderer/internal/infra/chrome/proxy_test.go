@@ -0,0 +1,76 @@
+package chrome
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"pdf-renderer/internal/config"
+)
+
+func TestHTTPTransport_Proxy(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.Chrome.Launch.ProxyServer = "proxy:3128"
+	cfg.Chrome.Launch.ProxyBypassList = "*.internal; .corp.example;<local>;10.0.0.0/8;https://direct.example:8443"
+
+	transport, err := HTTPTransport(cfg)
+	if err != nil {
+		t.Fatalf("HTTPTransport: %v", err)
+	}
+	tests := map[string]bool{ // URL → proxied
+		"https://cdn.example/app.css":        true,
+		"http://assets.internal/x.js":        false,
+		"https://a.b.corp.example/font.woff": false,
+		"http://intranet/logo.png":           false,
+		"http://10.1.2.3/img.png":            false,
+		"http://11.1.2.3/img.png":            true,
+		"https://direct.example:8443/a.css":  false,
+		"http://direct.example:8443/a.css":   true,
+		"https://direct.example/a.css":       true,
+		"http://localhost:8080/a.css":        false,
+		"http://127.0.0.1/a.css":             false,
+	}
+	for raw, proxied := range tests {
+		u, _ := url.Parse(raw)
+		got, err := transport.Proxy(&http.Request{URL: u})
+		if err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+		if proxied && (got == nil || got.String() != "http://proxy:3128") {
+			t.Errorf("%s: expected the proxy, got %v", raw, got)
+		}
+		if !proxied && got != nil {
+			t.Errorf("%s: expected a direct connection, got %v", raw, got)
+		}
+	}
+}
+
+func TestHTTPTransport_NoProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("HTTPS_PROXY", "")
+	// chrome.launch is ignored in remote mode.
+	remote := testConfig(1)
+	remote.Chrome.Mode = ModeRemote
+	remote.Chrome.Launch.ProxyServer = "http://proxy:3128"
+
+	for name, cfg := range map[string]config.Config{"unset": testConfig(1), "remote": remote} {
+		transport, err := HTTPTransport(cfg)
+		if err != nil {
+			t.Fatalf("%s: HTTPTransport: %v", name, err)
+		}
+		u, _ := url.Parse("https://cdn.example/app.css")
+		if got, _ := transport.Proxy(&http.Request{URL: u}); got != nil {
+			t.Errorf("%s: expected no proxy, got %v", name, got)
+		}
+	}
+}
+
+func TestHTTPTransport_InvalidProxy(t *testing.T) {
+	for _, server := range []string{"socks4://proxy:1080", "http://", "http=proxy:80;https=proxy:443"} {
+		cfg := testConfig(1)
+		cfg.Chrome.Launch.ProxyServer = server
+		if _, err := HTTPTransport(cfg); err == nil {
+			t.Errorf("%q: expected an error", server)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package chrome
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"pdf-renderer/internal/infra/logging"
+)
+
+const defaultRecycleCheckInterval = 30 * time.Second
+
+// recycleCheckInterval returns how often age and memory limits are checked, or 0 when no
+// recycle policy is configured.
+func (p *Pool) recycleCheckInterval() time.Duration {
+	r := p.cfg.Chrome.Recycle
+	if r.MaxRenders <= 0 && r.MaxAge <= 0 && r.MaxRSSMB <= 0 {
+		return 0
+	}
+	if r.CheckInterval > 0 {
+		return r.CheckInterval
+	}
+	return defaultRecycleCheckInterval
+}
+
+// recycleMonitor periodically checks every process against the recycle policy until the pool is closed.
+func (p *Pool) recycleMonitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.checkRecycle()
+		}
+	}
+}
+
+// checkRecycle marks processes that exceed a recycle limit for draining.
+func (p *Pool) checkRecycle() {
+	type candidate struct {
+		b          *browser
+		generation uint64
+		pid        int
+		startedAt  time.Time
+		renders    int
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	candidates := make([]candidate, 0, len(p.browsers))
+	for _, b := range p.browsers {
+		if b.starting || b.draining {
+			continue
+		}
+		candidates = append(candidates, candidate{b: b, generation: b.generation, pid: b.pid, startedAt: b.startedAt, renders: b.renders})
+	}
+	p.mu.Unlock()
+
+	r := p.cfg.Chrome.Recycle
+	for _, c := range candidates {
+		reason := ""
+		switch {
+		case r.MaxRenders > 0 && c.renders >= r.MaxRenders:
+			reason = "max_renders"
+		case r.MaxAge > 0 && !c.startedAt.IsZero() && time.Since(c.startedAt) >= r.MaxAge:
+			reason = "max_age"
+		case r.MaxRSSMB > 0 && c.pid > 0:
+			rss, err := processTreeRSS(c.pid)
+			if err != nil {
+				logging.Warn("Cannot read Chrome process memory", "process", c.b.id, "pid", c.pid, "error", err)
+				continue
+			}
+			if rss >= int64(r.MaxRSSMB)<<20 {
+				reason = "max_rss"
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		p.mu.Lock()
+		if !p.closed && c.b.generation == c.generation {
+			p.markRecycleLocked(c.b, reason)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// markRecycleLocked stops dispatching new tabs to b and gracefully restarts it. With several
+// processes, only one is drained at a time so the pool keeps serving; the others are picked
+// up by the next check.
+func (p *Pool) markRecycleLocked(b *browser, reason string) bool {
+	if b.draining || b.starting {
+		return false
+	}
+	if len(p.browsers) > 1 {
+		for _, other := range p.browsers {
+			if other != b && (other.draining || other.starting) {
+				return false
+			}
+		}
+	}
+
+	b.draining = true
+	b.recycleBy = reason
+	logging.Info("Recycling Chrome process", "process", b.id, "reason", reason, "renders", b.renders, "in_use", b.inUse)
+	go p.recycle(b, b.generation)
+	return true
+}
+
+// recycle restarts a process once its in-flight renders have finished.
+func (p *Pool) recycle(b *browser, generation uint64) {
+	if err := p.restartBrowser(b, generation, "recycle"); err != nil {
+		logging.Error("Chrome process recycle failed", "process", b.id, "error", err)
+	}
+}
+
+// processTreeRSS returns the resident memory in bytes of pid and all of its descendants
+// (Chromium renderer, GPU and utility processes), read from /proc.
+func processTreeRSS(pid int) (int64, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0, err
+	}
+
+	children := make(map[int][]int)
+	for _, e := range entries {
+		child, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		ppid, err := parentPID(child)
+		if err != nil {
+			continue // process exited while scanning
+		}
+		children[ppid] = append(children[ppid], child)
+	}
+
+	var total int64
+	found := false
+	queue := []int{pid}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		rss, err := residentBytes(cur)
+		if err != nil {
+			if cur == pid {
+				return 0, err
+			}
+			continue
+		}
+		found = true
+		total += rss
+		queue = append(queue, children[cur]...)
+	}
+	if !found {
+		return 0, fmt.Errorf("process %d not found", pid)
+	}
+	return total, nil
+}
+
+// parentPID reads the parent PID from /proc/<pid>/stat.
+func parentPID(pid int) (int, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	// The command name is wrapped in parentheses and may contain spaces; fields follow the last ')'.
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := bytes.Fields(data[i+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	return strconv.Atoi(string(fields[1]))
+}
+
+// residentBytes reads the resident set size from /proc/<pid>/statm.
+func residentBytes(pid int) (int64, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "statm"))
+	if err != nil {
+		return 0, err
+	}
+	fields := bytes.Fields(data)
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed statm for pid %d", pid)
+	}
+	pages, err := strconv.ParseInt(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * int64(os.Getpagesize()), nil
+}
@@ -0,0 +1,121 @@
+package chrome
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProcessTreeRSS_CurrentProcess(t *testing.T) {
+	if _, err := os.Stat("/proc/self/statm"); err != nil {
+		t.Skip("/proc not available")
+	}
+	rss, err := processTreeRSS(os.Getpid())
+	if err != nil {
+		t.Fatalf("processTreeRSS: %v", err)
+	}
+	if rss <= 0 {
+		t.Fatalf("expected positive RSS, got %d", rss)
+	}
+	if ppid, err := parentPID(os.Getpid()); err != nil || ppid != os.Getppid() {
+		t.Fatalf("expected parent pid %d, got %d (%v)", os.Getppid(), ppid, err)
+	}
+	if _, err := processTreeRSS(-1); err == nil {
+		t.Fatalf("expected error for unknown pid")
+	}
+}
+
+func TestRecycleCheckInterval(t *testing.T) {
+	cfg := testConfig(1)
+	if got := newTestPool(cfg, 1).recycleCheckInterval(); got != 0 {
+		t.Fatalf("expected monitor disabled without policy, got %v", got)
+	}
+	cfg.Chrome.Recycle.MaxAge = time.Hour
+	if got := newTestPool(cfg, 1).recycleCheckInterval(); got != defaultRecycleCheckInterval {
+		t.Fatalf("expected default interval, got %v", got)
+	}
+	cfg.Chrome.Recycle.CheckInterval = time.Second
+	if got := newTestPool(cfg, 1).recycleCheckInterval(); got != time.Second {
+		t.Fatalf("expected configured interval, got %v", got)
+	}
+}
+
+func waitForRestarts(t *testing.T, p *Pool, process int, want uint64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if p.Stats(1).Processes[process].Restarts >= want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("process %d not restarted: %+v", process, p.Stats(1).Processes)
+}
+
+func TestPool_RecyclesAfterMaxRendersOnceDrained(t *testing.T) {
+	cfg := testConfig(2)
+	cfg.PDF.ChromePath = "/bin/true"
+	cfg.Chrome.Recycle.MaxRenders = 2
+	p := newTestPool(cfg, 2)
+	defer p.Close()
+
+	tab1, _ := p.Acquire(context.Background())
+	tab2, _ := p.Acquire(context.Background())
+	if st := p.Stats(1).Processes[0]; !st.Draining || st.Renders != 2 {
+		t.Fatalf("expected process draining after max renders, got %+v", st)
+	}
+
+	// A draining process receives no new tabs.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := p.Acquire(ctx); err == nil {
+		t.Fatalf("expected draining process to be excluded from dispatch")
+	}
+
+	// In-flight renders are not interrupted; the restart happens after the last release.
+	p.Release(tab1, nil)
+	if st := p.Stats(1).Processes[0]; st.Restarts != 0 {
+		t.Fatalf("expected no restart while tabs are in flight, got %+v", st)
+	}
+	p.Release(tab2, nil)
+	waitForRestarts(t, p, 0, 1)
+
+	st := p.Stats(1).Processes[0]
+	if st.Draining || st.Renders != 0 {
+		t.Fatalf("expected fresh process after recycle, got %+v", st)
+	}
+	tab, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected acquire after recycle, got %v", err)
+	}
+	p.Release(tab, nil)
+}
+
+func TestPool_RecyclesOneProcessAtATime(t *testing.T) {
+	cfg := testConfig(2)
+	cfg.PDF.ChromePath = "/bin/true"
+	cfg.Chrome.Recycle.MaxAge = time.Millisecond
+	p := newTestPool(cfg, 1, 1)
+	defer p.Close()
+	for _, b := range p.browsers {
+		b.startedAt = time.Now().Add(-time.Hour)
+		b.inUse = 1 // keep both busy so the drain does not complete
+	}
+
+	p.checkRecycle()
+	st := p.Stats(1)
+	if !st.Processes[0].Draining || st.Processes[1].Draining {
+		t.Fatalf("expected only the first process draining, got %+v", st.Processes)
+	}
+
+	// The other process is still serving once capacity frees up.
+	p.mu.Lock()
+	p.browsers[1].inUse = 0
+	p.mu.Unlock()
+	tab, err := p.Acquire(context.Background())
+	if err != nil || tab.Process() != 1 {
+		t.Fatalf("expected tab on the non-draining process, got %v (%v)", tab.Process(), err)
+	}
+	p.Release(tab, nil)
+}
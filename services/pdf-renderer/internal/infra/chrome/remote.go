@@ -0,0 +1,104 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+
+	"pdf-renderer/internal/config"
+	"pdf-renderer/internal/infra/logging"
+)
+
+// Chrome modes (chrome.mode).
+const (
+	ModeLocal  = "local"  // launch Chromium as a child process (default)
+	ModeRemote = "remote" // connect to an external Chromium over the DevTools protocol
+)
+
+const maxReconnectBackoff = 30 * time.Second
+
+// IsRemote reports whether cfg connects to an external Chromium instead of launching one.
+func IsRemote(cfg config.Config) bool {
+	return strings.EqualFold(strings.TrimSpace(cfg.Chrome.Mode), ModeRemote)
+}
+
+// validateMode checks chrome.mode and, in remote mode, chrome.remote_url.
+func validateMode(cfg config.Config) error {
+	switch strings.ToLower(strings.TrimSpace(cfg.Chrome.Mode)) {
+	case "", ModeLocal:
+		return nil
+	case ModeRemote:
+		if cfg.Chrome.RemoteURL == "" {
+			return fmt.Errorf("chrome.remote_url is required in remote mode")
+		}
+		u, err := neturl.Parse(cfg.Chrome.RemoteURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid chrome.remote_url %q", cfg.Chrome.RemoteURL)
+		}
+		switch u.Scheme {
+		case "ws", "wss", "http", "https":
+			return nil
+		}
+		return fmt.Errorf("chrome.remote_url must be a ws://, wss://, http:// or https:// URL")
+	default:
+		return fmt.Errorf("invalid chrome.mode %q (expected %q or %q)", cfg.Chrome.Mode, ModeLocal, ModeRemote)
+	}
+}
+
+// NewRemoteAllocator returns an allocator connected to chrome.remote_url. A URL without a
+// /devtools/browser/<id> path (e.g. http://chrome:9222) is resolved through /json/version on
+// every connect, so reconnecting finds the new browser after the remote Chromium restarts.
+func NewRemoteAllocator(parent context.Context, cfg config.Config) (context.Context, context.CancelFunc) {
+	return chromedp.NewRemoteAllocator(parent, cfg.Chrome.RemoteURL)
+}
+
+// watchRemote reconnects b when its connection to the remote browser is lost (or could not be
+// established), backing off exponentially between failed attempts.
+func (p *Pool) watchRemote(b *browser, generation uint64, browserCtx context.Context, connected bool) {
+	p.mu.Lock()
+	if connected {
+		b.reconnectAttempts = 0
+	} else {
+		b.reconnectAttempts++
+	}
+	attempts := b.reconnectAttempts
+	p.mu.Unlock()
+
+	if connected {
+		select {
+		case <-p.done:
+			return
+		case <-browserCtx.Done():
+		}
+		logging.Warn("Remote Chrome connection lost; reconnecting", "process", b.id, "remote_url", p.cfg.Chrome.RemoteURL)
+	} else {
+		delay := reconnectBackoff(attempts)
+		logging.Warn("Remote Chrome unavailable; retrying", "process", b.id, "remote_url", p.cfg.Chrome.RemoteURL, "retry_in", delay.String())
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-p.done:
+			return
+		case <-timer.C:
+		}
+	}
+
+	if err := p.restartBrowser(b, generation, "reconnect"); err != nil {
+		logging.Error("Remote Chrome reconnect failed", "process", b.id, "error", err)
+	}
+}
+
+// reconnectBackoff returns 1s, 2s, 4s, ... capped at maxReconnectBackoff.
+func reconnectBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 6 {
+		return maxReconnectBackoff
+	}
+	return min(time.Second<<(attempts-1), maxReconnectBackoff)
+}
@@ -0,0 +1,91 @@
+package chrome
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidateMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		url     string
+		wantErr bool
+	}{
+		{"default", "", "", false},
+		{"local", "local", "", false},
+		{"remote ws", "remote", "ws://chrome:9222/devtools/browser/abc", false},
+		{"remote http discovery", "Remote", "http://chrome:9222", false},
+		{"remote without url", "remote", "", true},
+		{"remote bad scheme", "remote", "ftp://chrome:9222", true},
+		{"remote without host", "remote", "ws:///devtools", true},
+		{"unknown mode", "sidecar", "", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig(1)
+			cfg.Chrome.Mode = tc.mode
+			cfg.Chrome.RemoteURL = tc.url
+			if err := validateMode(cfg); (err != nil) != tc.wantErr {
+				t.Fatalf("validateMode() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	want := []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, attempts := range []int{0, 1, 2, 3, 5, 6, 40} {
+		if got := reconnectBackoff(attempts); got != want[i] {
+			t.Fatalf("reconnectBackoff(%d) = %v, want %v", attempts, got, want[i])
+		}
+	}
+}
+
+func TestNewPool_InvalidMode(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.Chrome.Mode = "remote"
+	if _, err := NewPool(cfg); err == nil {
+		t.Fatalf("expected error without chrome.remote_url")
+	}
+}
+
+func TestNewPool_RemoteReconnectsUntilAvailable(t *testing.T) {
+	// A DevTools endpoint that is not serving a browser yet: discovery fails on every connect.
+	var discoveries atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json/version" {
+			discoveries.Add(1)
+		}
+		http.Error(w, "starting", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig(1)
+	cfg.Chrome.Mode = "remote"
+	cfg.Chrome.RemoteURL = srv.URL
+
+	p, err := NewPool(cfg)
+	if err != nil {
+		t.Fatalf("expected remote pool to start while the browser is unavailable, got %v", err)
+	}
+	defer p.Close()
+
+	if dir := p.Stats(1).ProfileDir; dir != "" {
+		t.Fatalf("expected no local profile in remote mode, got %q", dir)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for discoveries.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected reconnect attempts, got %d discoveries", discoveries.Load())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if p.Stats(1).Restarts < 1 {
+		t.Fatalf("expected reconnect to restart the process")
+	}
+}
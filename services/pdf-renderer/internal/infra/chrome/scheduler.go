@@ -0,0 +1,297 @@
+package chrome
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"pdf-renderer/internal/infra/metrics"
+)
+
+// Priority is the scheduling class of a tab acquisition.
+type Priority int
+
+const (
+	// PriorityInteractive is for users waiting on a response; it is always served before batch work.
+	PriorityInteractive Priority = iota
+	// PriorityBatch is for bulk jobs that tolerate longer queueing.
+	PriorityBatch
+
+	numPriorities
+)
+
+const (
+	defaultInteractiveWaitTimeout = 5 * time.Second
+	defaultBatchWaitTimeout       = 60 * time.Second
+)
+
+var (
+	// ErrQueueFull is returned when the scheduler queue (global or per tenant) is at capacity.
+	ErrQueueFull = errors.New("chrome pool: render queue is full")
+	// ErrQueueTimeout is returned when no tab became available within the class wait timeout.
+	ErrQueueTimeout = errors.New("chrome pool: timed out waiting for a free tab")
+)
+
+// ParsePriority parses "interactive" or "batch" (case-insensitive). Empty means interactive.
+func ParsePriority(s string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "interactive":
+		return PriorityInteractive, true
+	case "batch":
+		return PriorityBatch, true
+	}
+	return PriorityInteractive, false
+}
+
+func (pr Priority) String() string {
+	if pr == PriorityBatch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+// AcquireOptions identifies who is asking for a tab and how urgently.
+type AcquireOptions struct {
+	Tenant   string // fairness key; requests without a tenant share one queue
+	Priority Priority
+}
+
+// QueueStats is a snapshot of the scheduler for observability.
+type QueueStats struct {
+	Depth       int            `json:"depth"`
+	Interactive int            `json:"interactive"`
+	Batch       int            `json:"batch"`
+	Tenants     map[string]int `json:"tenants"`
+	OldestWait  float64        `json:"oldest_wait_secs"`
+	Enqueued    uint64         `json:"enqueued_total"`
+	Rejected    uint64         `json:"rejected_total"`
+	TimedOut    uint64         `json:"timed_out_total"`
+}
+
+// grant is a tab slot reserved on a browser for a queued waiter.
+type grant struct {
+	b          *browser
+	generation uint64
+}
+
+type waiter struct {
+	tenant   string
+	priority Priority
+	position int // 1-based queue position at enqueue time
+	enqueued time.Time
+	ready    chan grant // buffered; receives the reserved slot, closed when the pool closes
+}
+
+// tenantQueue holds the waiters of one tenant within a priority class, in FIFO order.
+type tenantQueue struct {
+	tenant  string
+	waiters []*waiter
+}
+
+// classQueue round-robins between tenants so one tenant's backlog cannot starve the others.
+type classQueue struct {
+	tenants map[string]*tenantQueue
+	ring    []*tenantQueue // tenants with queued waiters, in service order
+	next    int
+}
+
+// scheduler orders waiting Acquire calls: interactive before batch, and within a class one
+// waiter per tenant in turn. All fields are guarded by Pool.mu.
+type scheduler struct {
+	classes [numPriorities]classQueue
+	depth   int
+	tenants map[string]int // queued waiters per tenant, across classes
+
+	enqueued atomic.Uint64
+	rejected atomic.Uint64
+	timedOut atomic.Uint64
+}
+
+// push queues w, enforcing the global and per-tenant depth limits (0 = unlimited).
+func (s *scheduler) push(w *waiter, maxDepth, maxTenantDepth int) error {
+	if (maxDepth > 0 && s.depth >= maxDepth) || (maxTenantDepth > 0 && s.tenants[w.tenant] >= maxTenantDepth) {
+		s.rejected.Add(1)
+		return ErrQueueFull
+	}
+
+	cq := &s.classes[w.priority]
+	if cq.tenants == nil {
+		cq.tenants = make(map[string]*tenantQueue)
+	}
+	tq := cq.tenants[w.tenant]
+	if tq == nil {
+		tq = &tenantQueue{tenant: w.tenant}
+		cq.tenants[w.tenant] = tq
+		cq.ring = append(cq.ring, tq)
+	}
+	tq.waiters = append(tq.waiters, w)
+
+	if s.tenants == nil {
+		s.tenants = make(map[string]int)
+	}
+	s.tenants[w.tenant]++
+	s.depth++
+	s.enqueued.Add(1)
+	metrics.QueueDepth.Inc()
+	w.position = s.positionOf(w)
+	return nil
+}
+
+// pop removes and returns the next waiter to serve, or nil if the queue is empty.
+func (s *scheduler) pop() *waiter {
+	for pr := range s.classes {
+		cq := &s.classes[pr]
+		if len(cq.ring) == 0 {
+			continue
+		}
+		if cq.next >= len(cq.ring) {
+			cq.next = 0
+		}
+		tq := cq.ring[cq.next]
+		w := tq.waiters[0]
+		tq.waiters = tq.waiters[1:]
+		if len(tq.waiters) == 0 {
+			cq.removeTenant(cq.next)
+		} else {
+			cq.next++
+		}
+		s.forget(w)
+		return w
+	}
+	return nil
+}
+
+// remove drops w from the queue. It reports false if w was no longer queued (already served).
+func (s *scheduler) remove(w *waiter) bool {
+	cq := &s.classes[w.priority]
+	tq := cq.tenants[w.tenant]
+	if tq == nil {
+		return false
+	}
+	for i, other := range tq.waiters {
+		if other != w {
+			continue
+		}
+		tq.waiters = append(tq.waiters[:i], tq.waiters[i+1:]...)
+		if len(tq.waiters) == 0 {
+			for j, r := range cq.ring {
+				if r == tq {
+					cq.removeTenant(j)
+					break
+				}
+			}
+		}
+		s.forget(w)
+		return true
+	}
+	return false
+}
+
+// closeAll fails every queued waiter (used when the pool closes).
+func (s *scheduler) closeAll() {
+	for w := s.pop(); w != nil; w = s.pop() {
+		close(w.ready)
+	}
+}
+
+func (s *scheduler) forget(w *waiter) {
+	s.depth--
+	metrics.QueueDepth.Dec()
+	if s.tenants[w.tenant]--; s.tenants[w.tenant] <= 0 {
+		delete(s.tenants, w.tenant)
+	}
+}
+
+func (cq *classQueue) removeTenant(i int) {
+	delete(cq.tenants, cq.ring[i].tenant)
+	cq.ring = append(cq.ring[:i], cq.ring[i+1:]...)
+	if cq.next > i {
+		cq.next--
+	}
+	if cq.next >= len(cq.ring) {
+		cq.next = 0
+	}
+}
+
+// positionOf estimates how many waiters will be served before (and including) w, given the
+// current queue: every higher class, plus one round of the other tenants per waiter ahead of
+// w in its own tenant queue.
+func (s *scheduler) positionOf(w *waiter) int {
+	pos := 0
+	for pr := Priority(0); pr < w.priority; pr++ {
+		for _, tq := range s.classes[pr].ring {
+			pos += len(tq.waiters)
+		}
+	}
+	cq := &s.classes[w.priority]
+	own := cq.tenants[w.tenant]
+	ahead := len(own.waiters) // including w
+	for _, tq := range cq.ring {
+		if tq == own {
+			pos += ahead
+			continue
+		}
+		pos += min(len(tq.waiters), ahead)
+	}
+	return pos
+}
+
+func (s *scheduler) stats() QueueStats {
+	qs := QueueStats{
+		Depth:    s.depth,
+		Tenants:  make(map[string]int, len(s.tenants)),
+		Enqueued: s.enqueued.Load(),
+		Rejected: s.rejected.Load(),
+		TimedOut: s.timedOut.Load(),
+	}
+	for tenant, n := range s.tenants {
+		qs.Tenants[tenant] = n
+	}
+	var oldest time.Time
+	for pr := range s.classes {
+		for _, tq := range s.classes[pr].ring {
+			if Priority(pr) == PriorityBatch {
+				qs.Batch += len(tq.waiters)
+			} else {
+				qs.Interactive += len(tq.waiters)
+			}
+			if len(tq.waiters) > 0 && (oldest.IsZero() || tq.waiters[0].enqueued.Before(oldest)) {
+				oldest = tq.waiters[0].enqueued
+			}
+		}
+	}
+	if !oldest.IsZero() {
+		qs.OldestWait = time.Since(oldest).Seconds()
+	}
+	return qs
+}
+
+// waitTimeout returns the configured maximum queueing time for a priority class.
+func (p *Pool) waitTimeout(pr Priority) time.Duration {
+	if pr == PriorityBatch {
+		if d := p.cfg.Scheduler.BatchWaitTimeout; d > 0 {
+			return d
+		}
+		return defaultBatchWaitTimeout
+	}
+	if d := p.cfg.Scheduler.InteractiveWaitTimeout; d > 0 {
+		return d
+	}
+	return defaultInteractiveWaitTimeout
+}
+
+// dispatchLocked hands free capacity to queued waiters in scheduling order.
+func (p *Pool) dispatchLocked() {
+	if p.closed {
+		return
+	}
+	for p.sched.depth > 0 {
+		b := p.leastLoadedLocked()
+		if b == nil {
+			return
+		}
+		w := p.sched.pop()
+		w.ready <- p.reserveLocked(b)
+	}
+}
@@ -0,0 +1,196 @@
+package chrome
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Priority
+		ok   bool
+	}{
+		{"", PriorityInteractive, true},
+		{"interactive", PriorityInteractive, true},
+		{" Batch ", PriorityBatch, true},
+		{"urgent", PriorityInteractive, false},
+	}
+	for _, tc := range tests {
+		got, ok := ParsePriority(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("ParsePriority(%q) = %v, %v; want %v, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+	if PriorityBatch.String() != "batch" || PriorityInteractive.String() != "interactive" {
+		t.Fatalf("unexpected priority names")
+	}
+}
+
+// enqueue starts an AcquireFor in the background and waits until it is queued.
+func enqueue(t *testing.T, p *Pool, opts AcquireOptions, served chan<- string, label string) {
+	t.Helper()
+	p.mu.Lock()
+	before := p.sched.enqueued.Load()
+	p.mu.Unlock()
+
+	go func() {
+		tab, err := p.AcquireFor(context.Background(), opts)
+		if err != nil {
+			served <- label + ":" + err.Error()
+			return
+		}
+		served <- label
+		p.Release(tab, nil)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for p.sched.enqueued.Load() == before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not queued", label)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScheduler_RoundRobinsTenantsAndPrefersInteractive(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.Scheduler.BatchWaitTimeout = 5 * time.Second
+	cfg.Scheduler.InteractiveWaitTimeout = 5 * time.Second
+	p := newTestPool(cfg, 1)
+	defer p.Close()
+
+	busy, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	served := make(chan string, 8)
+	enqueue(t, p, AcquireOptions{Tenant: "bulk", Priority: PriorityBatch}, served, "bulk-batch")
+	enqueue(t, p, AcquireOptions{Tenant: "a"}, served, "a1")
+	enqueue(t, p, AcquireOptions{Tenant: "a"}, served, "a2")
+	enqueue(t, p, AcquireOptions{Tenant: "a"}, served, "a3")
+	enqueue(t, p, AcquireOptions{Tenant: "b"}, served, "b1")
+
+	if st := p.Stats(1).Queue; st.Depth != 5 || st.Interactive != 4 || st.Batch != 1 || st.Tenants["a"] != 3 {
+		t.Fatalf("unexpected queue stats: %+v", st)
+	}
+
+	// Tabs are handed over one at a time as each holder releases its tab.
+	p.Release(busy, nil)
+	want := []string{"a1", "b1", "a2", "a3", "bulk-batch"}
+	for _, w := range want {
+		select {
+		case got := <-served:
+			if got != w {
+				t.Fatalf("expected %s to be served next, got %s", w, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", w)
+		}
+	}
+	if st := p.Stats(1).Queue; st.Depth != 0 || st.Enqueued != 5 {
+		t.Fatalf("unexpected queue stats after drain: %+v", st)
+	}
+}
+
+func TestScheduler_QueueDepthLimits(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.Scheduler.QueueDepth = 2
+	cfg.Scheduler.TenantQueueDepth = 1
+	p := newTestPool(cfg, 1)
+	defer p.Close()
+
+	busy, _ := p.Acquire(context.Background())
+	defer p.Release(busy, nil)
+
+	served := make(chan string, 4)
+	enqueue(t, p, AcquireOptions{Tenant: "a"}, served, "a1")
+	if _, err := p.AcquireFor(context.Background(), AcquireOptions{Tenant: "a"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected tenant queue full, got %v", err)
+	}
+	enqueue(t, p, AcquireOptions{Tenant: "b"}, served, "b1")
+	if _, err := p.AcquireFor(context.Background(), AcquireOptions{Tenant: "c"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected global queue full, got %v", err)
+	}
+	if got := p.Stats(1).Queue.Rejected; got != 2 {
+		t.Fatalf("expected 2 rejections, got %d", got)
+	}
+}
+
+func TestScheduler_WaitTimeoutAndCancellation(t *testing.T) {
+	cfg := testConfig(1)
+	cfg.Scheduler.InteractiveWaitTimeout = 20 * time.Millisecond
+	p := newTestPool(cfg, 1)
+	defer p.Close()
+
+	busy, _ := p.Acquire(context.Background())
+
+	if _, err := p.AcquireFor(context.Background(), AcquireOptions{Tenant: "a"}); !errors.Is(err, ErrQueueTimeout) {
+		t.Fatalf("expected queue timeout, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := p.AcquireFor(ctx, AcquireOptions{Tenant: "a"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected caller deadline, got %v", err)
+	}
+
+	st := p.Stats(1)
+	if st.Queue.Depth != 0 || st.Queue.TimedOut != 1 {
+		t.Fatalf("expected abandoned waiters removed, got %+v", st.Queue)
+	}
+
+	// Capacity is not leaked by abandoned waiters.
+	p.Release(busy, nil)
+	tab, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected free tab, got %v", err)
+	}
+	p.Release(tab, nil)
+	if st := p.Stats(1); st.InUse != 0 {
+		t.Fatalf("expected no tabs in use, got %d", st.InUse)
+	}
+}
+
+func TestScheduler_CloseFailsQueuedWaiters(t *testing.T) {
+	p := newTestPool(testConfig(1), 1)
+	busy, _ := p.Acquire(context.Background())
+	defer p.Release(busy, nil)
+
+	served := make(chan string, 1)
+	enqueue(t, p, AcquireOptions{Tenant: "a"}, served, "a1")
+	p.Close()
+
+	select {
+	case got := <-served:
+		if got == "a1" {
+			t.Fatalf("expected queued waiter to fail after close")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("queued waiter not released by close")
+	}
+}
+
+func TestScheduler_PositionOf(t *testing.T) {
+	var s scheduler
+	push := func(tenant string, pr Priority) *waiter {
+		w := &waiter{tenant: tenant, priority: pr, enqueued: time.Now(), ready: make(chan grant, 1)}
+		if err := s.push(w, 0, 0); err != nil {
+			t.Fatalf("push: %v", err)
+		}
+		return w
+	}
+	push("a", PriorityInteractive)
+	push("a", PriorityInteractive)
+	b1 := push("b", PriorityInteractive)
+	batch := push("c", PriorityBatch)
+
+	if b1.position != 2 {
+		t.Fatalf("expected b1 to be served second, got position %d", b1.position)
+	}
+	if batch.position != 4 {
+		t.Fatalf("expected batch after all interactive waiters, got position %d", batch.position)
+	}
+}
@@ -0,0 +1,121 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"pdf-renderer/internal/domain"
+)
+
+// Renderer is a deterministic, in-process domain.Renderer for unit and contract tests.
+// It produces a minimal but valid single-page PDF whose content depends only on the request,
+// so identical requests yield byte-identical output.
+type Renderer struct {
+	// Delay simulates render latency. Cancelling the context during the delay aborts the render.
+	Delay time.Duration
+	// Err, when set, is returned by every Render call.
+	Err error
+
+	renders atomic.Uint64
+	inUse   atomic.Int32
+	closed  atomic.Bool
+}
+
+// NewRenderer creates a fake renderer without latency or errors.
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+// Render returns a minimal PDF for req.
+func (r *Renderer) Render(ctx context.Context, req domain.RenderRequest) ([]byte, error) {
+	if r.closed.Load() {
+		return nil, fmt.Errorf("fake renderer is closed")
+	}
+	r.inUse.Add(1)
+	defer r.inUse.Add(-1)
+	r.renders.Add(1)
+
+	if r.Delay > 0 {
+		timer := time.NewTimer(r.Delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	} else if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return MinimalPDF(req), nil
+}
+
+// Renders returns the number of Render calls so far.
+func (r *Renderer) Renders() uint64 {
+	return r.renders.Load()
+}
+
+// Stats reports the number of renders currently in progress.
+func (r *Renderer) Stats() domain.RendererStats {
+	return domain.RendererStats{
+		Backend: "fake",
+		InUse:   int(r.inUse.Load()),
+	}
+}
+
+// Close makes subsequent renders fail.
+func (r *Renderer) Close() error {
+	r.closed.Store(true)
+	return nil
+}
+
+// MinimalPDF builds a valid single-page PDF sized to req.Paper that prints a digest of the request.
+func MinimalPDF(req domain.RenderRequest) []byte {
+	h := sha256.New()
+	h.Write([]byte(req.URL))
+	h.Write([]byte{0})
+	h.Write([]byte(req.HTML))
+	h.Write([]byte(strconv.FormatFloat(req.Margin, 'f', 2, 64)))
+	digest := hex.EncodeToString(h.Sum(nil))[:16]
+
+	width := strconv.FormatFloat(req.Paper.Width*72, 'f', 2, 64)
+	height := strconv.FormatFloat(req.Paper.Height*72, 'f', 2, 64)
+	content := "BT /F1 12 Tf 72 72 Td (html2pdf fake render " + digest + ") Tj ET"
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 " + width + " " + height + "] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Length " + strconv.Itoa(len(content)) + " >>\nstream\n" + content + "\nendstream",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n", len(objects)+1)
+	buf.WriteString("0000000000 65535 f \n")
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// compile-time check
+var _ domain.Renderer = (*Renderer)(nil)
@@ -0,0 +1,110 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"pdf-renderer/internal/config"
+	"pdf-renderer/internal/domain"
+)
+
+func testRequest() domain.RenderRequest {
+	return domain.RenderRequest{
+		HTML:   "<html><body>hello</body></html>",
+		Paper:  config.PaperSize{Width: 8.27, Height: 11.69},
+		Margin: 0.4,
+	}
+}
+
+func TestMinimalPDF_IsStructurallyValid(t *testing.T) {
+	pdf := MinimalPDF(testRequest())
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.")) {
+		t.Fatalf("missing PDF header")
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("missing EOF marker")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at xref table")
+	}
+
+	// Every in-use xref entry must point at the matching object header.
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 objects, got %d", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		want := strconv.Itoa(i+1) + " 0 obj"
+		if !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Fatalf("xref entry %d does not point at %q", i+1, want)
+		}
+	}
+
+	if !bytes.Contains(pdf, []byte("/MediaBox [0 0 595.44 841.68]")) {
+		t.Fatalf("expected media box derived from paper size")
+	}
+}
+
+func TestMinimalPDF_Deterministic(t *testing.T) {
+	a := MinimalPDF(testRequest())
+	b := MinimalPDF(testRequest())
+	if !bytes.Equal(a, b) {
+		t.Fatalf("expected identical output for identical requests")
+	}
+
+	other := testRequest()
+	other.HTML = "<html><body>bye</body></html>"
+	if bytes.Equal(a, MinimalPDF(other)) {
+		t.Fatalf("expected different output for different requests")
+	}
+}
+
+func TestRenderer_RenderStatsAndClose(t *testing.T) {
+	r := NewRenderer()
+	pdf, err := r.Render(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !bytes.Equal(pdf, MinimalPDF(testRequest())) {
+		t.Fatalf("expected MinimalPDF output")
+	}
+	if r.Renders() != 1 {
+		t.Fatalf("expected one render, got %d", r.Renders())
+	}
+	if s := r.Stats(); s.Backend != "fake" || s.InUse != 0 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+
+	_ = r.Close()
+	if _, err := r.Render(context.Background(), testRequest()); err == nil {
+		t.Fatalf("expected render to fail after close")
+	}
+}
+
+func TestRenderer_ErrorAndCancellation(t *testing.T) {
+	wantErr := errors.New("boom")
+	r := &Renderer{Err: wantErr}
+	if _, err := r.Render(context.Background(), testRequest()); !errors.Is(err, wantErr) {
+		t.Fatalf("expected configured error, got %v", err)
+	}
+
+	slow := &Renderer{Delay: time.Second}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := slow.Render(ctx, testRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
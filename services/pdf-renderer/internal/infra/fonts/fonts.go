@@ -0,0 +1,202 @@
+// Package fonts makes the font files of a directory available to every render. The files are
+// described by @font-face rules pointing at a synthetic origin (Host) whose requests the
+// renderer answers from the directory, so nothing has to be installed in the Chromium image.
+package fonts
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// Host is the origin font files are served from inside Chrome. It never leaves the renderer.
+const Host = "fonts.html2pdf.internal"
+
+// formats maps supported file extensions to their @font-face format() hint.
+var formats = map[string]string{
+	".ttf":   "truetype",
+	".otf":   "opentype",
+	".woff":  "woff",
+	".woff2": "woff2",
+}
+
+// Face is one font file.
+type Face struct {
+	Family string `json:"family"`
+	Weight int    `json:"weight"`
+	Style  string `json:"style"` // normal or italic
+	File   string `json:"file"`  // path relative to the fonts directory
+	Format string `json:"format"`
+}
+
+// Library holds the faces found in a fonts directory.
+type Library struct {
+	dir   string
+	faces []Face
+	css   string
+}
+
+// Load scans dir (recursively) for TrueType, OpenType and WOFF files. Family, weight and style
+// come from the name table of TrueType/OpenType files; WOFF files are named <Family>-<Style>.woff2.
+func Load(dir string) (*Library, error) {
+	l := &Library{dir: dir}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		format, ok := formats[strings.ToLower(filepath.Ext(path))]
+		if !ok || !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		face, err := describe(path, format)
+		if err != nil {
+			return fmt.Errorf("font %s: %w", rel, err)
+		}
+		face.File = filepath.ToSlash(rel)
+		l.faces = append(l.faces, face)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot load fonts directory: %w", err)
+	}
+	sort.Slice(l.faces, func(i, j int) bool {
+		a, b := l.faces[i], l.faces[j]
+		if a.Family != b.Family {
+			return a.Family < b.Family
+		}
+		if a.Weight != b.Weight {
+			return a.Weight < b.Weight
+		}
+		return a.Style < b.Style
+	})
+	l.css = fontFaceCSS(l.faces)
+	return l, nil
+}
+
+// Dir returns the fonts directory.
+func (l *Library) Dir() string { return l.dir }
+
+// Faces returns the loaded faces, sorted by family, weight and style.
+func (l *Library) Faces() []Face { return slices.Clone(l.faces) }
+
+// Families returns the distinct family names.
+func (l *Library) Families() []string {
+	var families []string
+	for _, f := range l.faces {
+		if len(families) == 0 || families[len(families)-1] != f.Family {
+			families = append(families, f.Family)
+		}
+	}
+	return families
+}
+
+// CSS returns the @font-face rules for every face; empty when the directory has no fonts.
+func (l *Library) CSS() string { return l.css }
+
+// Open returns the font file behind a URL on Host. ok is false for other URLs, unknown files
+// and a nil Library.
+func (l *Library) Open(rawURL string) (body []byte, contentType string, ok bool) {
+	if l == nil {
+		return nil, "", false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host != Host {
+		return nil, "", false
+	}
+	file := strings.TrimPrefix(u.Path, "/")
+	i := slices.IndexFunc(l.faces, func(f Face) bool { return f.File == file })
+	if i < 0 {
+		return nil, "", false
+	}
+	body, err = os.ReadFile(filepath.Join(l.dir, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, "", false
+	}
+	return body, "font/" + strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), "."), true
+}
+
+// URL returns the address a face is served from.
+func URL(f Face) string {
+	return (&url.URL{Scheme: "https", Host: Host, Path: "/" + f.File}).String()
+}
+
+func describe(path, format string) (Face, error) {
+	face := Face{Format: format, Weight: 400, Style: "normal"}
+	var subfamily string
+	if format == "truetype" || format == "opentype" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return face, err
+		}
+		f, err := sfnt.Parse(data)
+		if err != nil {
+			return face, err
+		}
+		face.Family = fontName(f, sfnt.NameIDTypographicFamily, sfnt.NameIDFamily)
+		subfamily = fontName(f, sfnt.NameIDTypographicSubfamily, sfnt.NameIDSubfamily)
+	}
+	if face.Family == "" {
+		stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		family, style, _ := strings.Cut(stem, "-")
+		face.Family = strings.TrimSpace(strings.ReplaceAll(family, "_", " "))
+		subfamily = style
+	}
+	face.Weight, face.Style = parseSubfamily(subfamily)
+	return face, nil
+}
+
+func fontName(f *sfnt.Font, ids ...sfnt.NameID) string {
+	var buf sfnt.Buffer
+	for _, id := range ids {
+		if name, err := f.Name(&buf, id); err == nil && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// weightNames maps subfamily words to CSS weights, longest first so "semibold" wins over "bold".
+var weightNames = []struct {
+	name   string
+	weight int
+}{
+	{"extralight", 200}, {"ultralight", 200}, {"extrabold", 800}, {"ultrabold", 800},
+	{"semibold", 600}, {"demibold", 600}, {"regular", 400}, {"medium", 500},
+	{"normal", 400}, {"black", 900}, {"heavy", 900}, {"light", 300}, {"thin", 100},
+	{"bold", 700}, {"book", 400},
+}
+
+// parseSubfamily derives weight and style from a subfamily such as "Semi Bold Italic".
+func parseSubfamily(s string) (weight int, style string) {
+	s = strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(s))
+	weight, style = 400, "normal"
+	if strings.Contains(s, "italic") || strings.Contains(s, "oblique") {
+		style = "italic"
+	}
+	for _, w := range weightNames {
+		if strings.Contains(s, w.name) {
+			return w.weight, style
+		}
+	}
+	return weight, style
+}
+
+func fontFaceCSS(faces []Face) string {
+	var b strings.Builder
+	quote := strings.NewReplacer(`\`, ``, `"`, ``)
+	for _, f := range faces {
+		fmt.Fprintf(&b, "@font-face{font-family:\"%s\";src:url(\"%s\") format(\"%s\");font-weight:%d;font-style:%s;font-display:block}\n",
+			quote.Replace(f.Family), URL(f), f.Format, f.Weight, f.Style)
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package fonts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go", "Go-Regular.ttf"), goregular.TTF)
+	writeFile(t, filepath.Join(dir, "go", "Go-BoldItalic.ttf"), gobolditalic.TTF)
+	writeFile(t, filepath.Join(dir, "Brand_Sans-SemiBold.woff2"), []byte("wOF2"))
+	writeFile(t, filepath.Join(dir, "README.txt"), []byte("not a font"))
+
+	lib, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []Face{
+		{Family: "Brand Sans", Weight: 600, Style: "normal", File: "Brand_Sans-SemiBold.woff2", Format: "woff2"},
+		{Family: "Go", Weight: 400, Style: "normal", File: "go/Go-Regular.ttf", Format: "truetype"},
+		{Family: "Go", Weight: 700, Style: "italic", File: "go/Go-BoldItalic.ttf", Format: "truetype"},
+	}
+	got := lib.Faces()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d faces, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("face %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if families := lib.Families(); strings.Join(families, ",") != "Brand Sans,Go" {
+		t.Fatalf("unexpected families %v", families)
+	}
+
+	css := lib.CSS()
+	for _, rule := range []string{
+		`font-family:"Go";src:url("https://fonts.html2pdf.internal/go/Go-BoldItalic.ttf") format("truetype");font-weight:700;font-style:italic`,
+		`font-family:"Brand Sans";src:url("https://fonts.html2pdf.internal/Brand_Sans-SemiBold.woff2") format("woff2");font-weight:600`,
+	} {
+		if !strings.Contains(css, rule) {
+			t.Fatalf("expected CSS to contain %s, got\n%s", rule, css)
+		}
+	}
+
+	body, ct, ok := lib.Open("https://fonts.html2pdf.internal/go/Go-Regular.ttf")
+	if !ok || len(body) != len(goregular.TTF) || ct != "font/ttf" {
+		t.Fatalf("unexpected Open result ok=%v ct=%q len=%d", ok, ct, len(body))
+	}
+	for _, u := range []string{
+		"https://fonts.html2pdf.internal/README.txt",
+		"https://fonts.html2pdf.internal/../secret.ttf",
+		"https://example.com/go/Go-Regular.ttf",
+	} {
+		if _, _, ok := lib.Open(u); ok {
+			t.Fatalf("expected %s not to be served", u)
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("expected an error for a missing directory")
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "broken.ttf"), []byte("garbage"))
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "broken.ttf") {
+		t.Fatalf("expected an error naming the broken font, got %v", err)
+	}
+}
+
+func TestParseSubfamily(t *testing.T) {
+	tests := []struct {
+		in     string
+		weight int
+		style  string
+	}{
+		{"Regular", 400, "normal"},
+		{"Semi Bold Italic", 600, "italic"},
+		{"ExtraLight", 200, "normal"},
+		{"Bold Oblique", 700, "italic"},
+		{"Black", 900, "normal"},
+		{"", 400, "normal"},
+	}
+	for _, tc := range tests {
+		if w, s := parseSubfamily(tc.in); w != tc.weight || s != tc.style {
+			t.Errorf("parseSubfamily(%q) = %d %s, want %d %s", tc.in, w, s, tc.weight, tc.style)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package metrics defines the Prometheus time series exported by the renderer on /ops/metrics.
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "html2pdf"
+
+// Registry holds every renderer metric plus the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RenderDuration measures the time from the cache lookup to a ready PDF, by input type
+	// (html|url), paper format and cache outcome (hit|miss|shared).
+	RenderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "render_duration_seconds",
+		Help:      "Time to produce a PDF, from the cache lookup to a ready document.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60},
+	}, []string{"input", "paper", "cache"})
+
+	// PDFSize is the size distribution of PDFs sent to clients.
+	PDFSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pdf_size_bytes",
+		Help:      "Size of generated PDFs.",
+		Buckets:   prometheus.ExponentialBuckets(8<<10, 4, 8), // 8 KiB … 128 MiB
+	})
+
+	// CacheRequests counts PDF cache lookups by result (hit|miss).
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "PDF cache lookups by result.",
+	}, []string{"result"})
+
+	// AssetRequests counts sub-resources handled by the asset cache by result
+	// (seed|hit|revalidated|stale|miss|offline_miss|bypass).
+	AssetRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "asset_cache_requests_total",
+		Help:      "Sub-resource requests handled by the asset cache, by result.",
+	}, []string{"result"})
+
+	// QueueWait measures how long acquisitions waited for a Chrome tab, by priority class.
+	QueueWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "queue_wait_seconds",
+		Help:      "Time spent waiting for a free Chrome tab.",
+		Buckets:   []float64{0, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60},
+	}, []string{"priority"})
+
+	// QueueDepth is the number of acquisitions currently waiting for a tab.
+	QueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_depth",
+		Help:      "Acquisitions currently waiting for a free Chrome tab.",
+	})
+
+	// AcquireTimeouts counts acquisitions that did not get a tab within the class wait timeout.
+	AcquireTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "acquire_timeouts_total",
+		Help:      "Tab acquisitions that timed out in the queue.",
+	}, []string{"priority"})
+
+	// QueueRejections counts acquisitions rejected because the queue was full.
+	QueueRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queue_rejections_total",
+		Help:      "Tab acquisitions rejected because the queue was full.",
+	}, []string{"priority"})
+
+	// TabsInUse is the number of Chrome tabs currently rendering.
+	TabsInUse = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chrome_tabs_in_use",
+		Help:      "Chrome tabs currently in use.",
+	})
+
+	// PoolRestarts counts Chrome process restarts by reason (session|manual|recycle|probe|reconnect).
+	PoolRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chrome_restarts_total",
+		Help:      "Chrome process restarts by reason.",
+	}, []string{"reason"})
+
+	// RenderFailures counts requests whose render failed, by reason
+	// (busy|timeout|session|script|rejected|error). Client cancellations are not failures.
+	RenderFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "render_failures_total",
+		Help:      "Requests whose render failed, by reason.",
+	}, []string{"reason"})
+
+	// RenderCancellations counts requests abandoned by their client before the PDF was ready.
+	RenderCancellations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "render_cancellations_total",
+		Help:      "Requests whose client disconnected before the PDF was ready.",
+	})
+
+	// SessionInterruptions counts renders that failed because the Chrome session broke.
+	SessionInterruptions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chrome_session_interruptions_total",
+		Help:      "Renders interrupted by a broken Chrome session.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RenderDuration,
+		PDFSize,
+		CacheRequests,
+		AssetRequests,
+		QueueWait,
+		QueueDepth,
+		AcquireTimeouts,
+		QueueRejections,
+		TabsInUse,
+		PoolRestarts,
+		SessionInterruptions,
+		RenderFailures,
+		RenderCancellations,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
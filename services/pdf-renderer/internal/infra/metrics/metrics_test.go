@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHandler_ExposesRendererMetrics(t *testing.T) {
+	RenderDuration.WithLabelValues("html", "A4", "miss").Observe(0.2)
+	PDFSize.Observe(1024)
+	CacheRequests.WithLabelValues("hit").Inc()
+	AssetRequests.WithLabelValues("seed").Inc()
+	QueueWait.WithLabelValues("interactive").Observe(0)
+	AcquireTimeouts.WithLabelValues("batch").Inc()
+	PoolRestarts.WithLabelValues("probe").Inc()
+	SessionInterruptions.Inc()
+	RenderFailures.WithLabelValues("timeout").Inc()
+	RenderCancellations.Inc()
+
+	app := fiber.New()
+	app.Get("/ops/metrics", Handler())
+	resp, err := app.Test(httptest.NewRequest("GET", "/ops/metrics", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("expected Prometheus text format, got %q", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`html2pdf_render_duration_seconds_bucket{cache="miss",input="html",paper="A4"`,
+		"html2pdf_pdf_size_bytes_count",
+		`html2pdf_cache_requests_total{result="hit"}`,
+		`html2pdf_asset_cache_requests_total{result="seed"}`,
+		`html2pdf_queue_wait_seconds_count{priority="interactive"}`,
+		`html2pdf_acquire_timeouts_total{priority="batch"}`,
+		`html2pdf_chrome_restarts_total{reason="probe"}`,
+		"html2pdf_chrome_session_interruptions_total",
+		`html2pdf_render_failures_total{reason="timeout"}`,
+		"html2pdf_render_cancellations_total",
+		"html2pdf_queue_depth",
+		"html2pdf_chrome_tabs_in_use",
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("expected metrics output to contain %q", want)
+		}
+	}
+}